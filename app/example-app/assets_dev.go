@@ -0,0 +1,11 @@
+//go:build dev
+
+package main
+
+import "net/http"
+
+// staticFS returns the files served under /static. Development builds read
+// them from disk so CSS rebuilt by Tailwind shows up without a restart.
+func staticFS() http.FileSystem {
+	return http.Dir("static")
+}
@@ -0,0 +1,23 @@
+//go:build !dev
+
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// staticFiles is compiled into production builds so the binary runs from any directory
+//
+//go:embed all:static
+var staticFiles embed.FS
+
+// staticFS returns the files served under /static
+func staticFS() http.FileSystem {
+	sub, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		panic(err)
+	}
+	return http.FS(sub)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/securecookie"
+	"github.com/nick-friedrich/beesting/pkg/beesting"
+)
+
+// csrfCookieName and csrfMaxAge match the defaults of gorilla/csrf
+const (
+	csrfCookieName = "_gorilla_csrf"
+	csrfMaxAge     = 12 * 60 * 60
+)
+
+// csrfProtect is csrf.Protect with key rotation: CSRF cookies signed with
+// one of the previous keys are re-signed with the current key, keys[0], so
+// open forms keep working after the key changed
+func csrfProtect(keys [][]byte, opts ...csrf.Option) beesting.Middleware {
+	opts = append([]csrf.Option{csrf.Path("/")}, opts...)
+	protect := csrf.Protect(keys[0], opts...)
+
+	current := csrfCodec(keys[0])
+	previous := make([]*securecookie.SecureCookie, len(keys)-1)
+	for i, key := range keys[1:] {
+		previous[i] = csrfCodec(key)
+	}
+
+	return func(next http.Handler) http.Handler {
+		protected := protect(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cookie, err := r.Cookie(csrfCookieName); err == nil && len(previous) > 0 {
+				if resigned, ok := resignCSRFCookie(cookie.Value, current, previous); ok {
+					r = withCookie(r, csrfCookieName, resigned)
+					http.SetCookie(w, &http.Cookie{
+						Name:     csrfCookieName,
+						Value:    resigned,
+						Path:     "/",
+						MaxAge:   csrfMaxAge,
+						HttpOnly: true,
+						Secure:   true,
+						SameSite: http.SameSiteLaxMode,
+					})
+				}
+			}
+			protected.ServeHTTP(w, r)
+		})
+	}
+}
+
+// csrfCodec decodes and encodes cookies the way gorilla/csrf does
+func csrfCodec(key []byte) *securecookie.SecureCookie {
+	codec := securecookie.New(key, nil)
+	codec.SetSerializer(securecookie.JSONEncoder{})
+	codec.MaxAge(csrfMaxAge)
+	return codec
+}
+
+// resignCSRFCookie re-signs a cookie value that only a previous key accepts
+func resignCSRFCookie(value string, current *securecookie.SecureCookie, previous []*securecookie.SecureCookie) (string, bool) {
+	var token []byte
+	if current.Decode(csrfCookieName, value, &token) == nil {
+		return "", false
+	}
+
+	for _, codec := range previous {
+		if codec.Decode(csrfCookieName, value, &token) != nil {
+			continue
+		}
+		encoded, err := current.Encode(csrfCookieName, token)
+		if err != nil {
+			return "", false
+		}
+		return encoded, true
+	}
+	return "", false
+}
+
+// withCookie returns a copy of r with the value of the named cookie replaced
+func withCookie(r *http.Request, name, value string) *http.Request {
+	cookies := r.Cookies()
+	parts := make([]string, len(cookies))
+	for i, cookie := range cookies {
+		if cookie.Name == name {
+			cookie.Value = value
+		}
+		parts[i] = cookie.String()
+	}
+
+	r = r.Clone(r.Context())
+	r.Header.Set("Cookie", strings.Join(parts, "; "))
+	return r
+}
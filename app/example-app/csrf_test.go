@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/securecookie"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestResignCSRFCookie(t *testing.T) {
+	current := csrfCodec(testKey(1))
+	previous := []*securecookie.SecureCookie{csrfCodec(testKey(2)), csrfCodec(testKey(3))}
+	token := []byte("the token")
+
+	encode := func(codec *securecookie.SecureCookie) string {
+		value, err := codec.Encode(csrfCookieName, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		ok    bool
+	}{
+		{"current key", encode(current), false},
+		{"previous key", encode(previous[0]), true},
+		{"older previous key", encode(previous[1]), true},
+		{"unknown key", encode(csrfCodec(testKey(4))), false},
+		{"garbage", "not a cookie", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resigned, ok := resignCSRFCookie(tt.value, current, previous)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				if resigned != "" {
+					t.Errorf("resigned = %q, want empty", resigned)
+				}
+				return
+			}
+
+			var got []byte
+			if err := current.Decode(csrfCookieName, resigned, &got); err != nil {
+				t.Fatalf("resigned cookie doesn't decode with the current key: %v", err)
+			}
+			if !bytes.Equal(got, token) {
+				t.Errorf("token = %q, want %q", got, token)
+			}
+		})
+	}
+}
+
+func TestWithCookie(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "a", Value: "1"})
+	r.AddCookie(&http.Cookie{Name: csrfCookieName, Value: "old"})
+
+	r2 := withCookie(r, csrfCookieName, "new")
+
+	if c, _ := r2.Cookie(csrfCookieName); c == nil || c.Value != "new" {
+		t.Errorf("cookie = %v, want new", c)
+	}
+	if c, _ := r2.Cookie("a"); c == nil || c.Value != "1" {
+		t.Errorf("other cookie = %v, want 1", c)
+	}
+	if c, _ := r.Cookie(csrfCookieName); c.Value != "old" {
+		t.Errorf("original request changed to %q", c.Value)
+	}
+}
+
+// A form rendered before the key rotation is still accepted after it
+func TestCSRFProtectAcceptsPreviousKey(t *testing.T) {
+	oldKey, newKey := testKey(1), testKey(2)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, csrf.Token(r))
+	})
+	opts := []csrf.Option{csrf.TrustedOrigins([]string{"example.com"}), csrf.FieldName("_csrf")}
+
+	// Render the form with the old key
+	before := csrfProtect([][]byte{oldKey}, opts...)(handler)
+	w := httptest.NewRecorder()
+	before.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/", nil))
+	token := w.Body.String()
+	cookies := w.Result().Cookies()
+
+	submit := func(keys [][]byte) *httptest.ResponseRecorder {
+		form := url.Values{"_csrf": {token}}
+		r := httptest.NewRequest(http.MethodPost, "https://example.com/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("Origin", "https://example.com")
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		csrfProtect(keys, opts...)(handler).ServeHTTP(w, r)
+		return w
+	}
+
+	if w := submit([][]byte{newKey}); w.Code != http.StatusForbidden {
+		t.Errorf("without the previous key: status %d, want 403", w.Code)
+	}
+
+	w = submit([][]byte{newKey, oldKey})
+	if w.Code != http.StatusOK {
+		t.Fatalf("with the previous key: status %d, want 200", w.Code)
+	}
+	var resigned *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == csrfCookieName {
+			resigned = c
+		}
+	}
+	if resigned == nil {
+		t.Fatal("cookie was not re-signed with the new key")
+	}
+	var got []byte
+	if err := csrfCodec(newKey).Decode(csrfCookieName, resigned.Value, &got); err != nil {
+		t.Errorf("re-signed cookie doesn't decode with the new key: %v", err)
+	}
+}
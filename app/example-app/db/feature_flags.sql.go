@@ -0,0 +1,124 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: feature_flags.sql
+
+package db
+
+import (
+	"context"
+)
+
+const deleteFeatureFlag = `-- name: DeleteFeatureFlag :exec
+DELETE FROM feature_flags
+WHERE key = ?
+`
+
+func (q *Queries) DeleteFeatureFlag(ctx context.Context, key string) error {
+	_, err := q.db.ExecContext(ctx, deleteFeatureFlag, key)
+	return err
+}
+
+const getFeatureFlag = `-- name: GetFeatureFlag :one
+SELECT "key", description, enabled, rollout_percent, roles, user_ids, created_at, updated_at FROM feature_flags
+WHERE key = ?
+LIMIT 1
+`
+
+func (q *Queries) GetFeatureFlag(ctx context.Context, key string) (FeatureFlag, error) {
+	row := q.db.QueryRowContext(ctx, getFeatureFlag, key)
+	var i FeatureFlag
+	err := row.Scan(
+		&i.Key,
+		&i.Description,
+		&i.Enabled,
+		&i.RolloutPercent,
+		&i.Roles,
+		&i.UserIds,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listFeatureFlags = `-- name: ListFeatureFlags :many
+SELECT "key", description, enabled, rollout_percent, roles, user_ids, created_at, updated_at FROM feature_flags
+ORDER BY key
+`
+
+func (q *Queries) ListFeatureFlags(ctx context.Context) ([]FeatureFlag, error) {
+	rows, err := q.db.QueryContext(ctx, listFeatureFlags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FeatureFlag{}
+	for rows.Next() {
+		var i FeatureFlag
+		if err := rows.Scan(
+			&i.Key,
+			&i.Description,
+			&i.Enabled,
+			&i.RolloutPercent,
+			&i.Roles,
+			&i.UserIds,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertFeatureFlag = `-- name: UpsertFeatureFlag :one
+INSERT INTO feature_flags (key, description, enabled, rollout_percent, roles, user_ids)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (key) DO UPDATE SET
+    description = excluded.description,
+    enabled = excluded.enabled,
+    rollout_percent = excluded.rollout_percent,
+    roles = excluded.roles,
+    user_ids = excluded.user_ids,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING "key", description, enabled, rollout_percent, roles, user_ids, created_at, updated_at
+`
+
+type UpsertFeatureFlagParams struct {
+	Key            string `json:"key"`
+	Description    string `json:"description"`
+	Enabled        bool   `json:"enabled"`
+	RolloutPercent int64  `json:"rollout_percent"`
+	Roles          string `json:"roles"`
+	UserIds        string `json:"user_ids"`
+}
+
+func (q *Queries) UpsertFeatureFlag(ctx context.Context, arg UpsertFeatureFlagParams) (FeatureFlag, error) {
+	row := q.db.QueryRowContext(ctx, upsertFeatureFlag,
+		arg.Key,
+		arg.Description,
+		arg.Enabled,
+		arg.RolloutPercent,
+		arg.Roles,
+		arg.UserIds,
+	)
+	var i FeatureFlag
+	err := row.Scan(
+		&i.Key,
+		&i.Description,
+		&i.Enabled,
+		&i.RolloutPercent,
+		&i.Roles,
+		&i.UserIds,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -5,6 +5,6 @@ CREATE INDEX IF NOT EXISTS idx_posts_slug ON posts(slug);
 -- +goose StatementEnd
 -- +goose Down
 -- +goose StatementBegin
-DROP INDEX IF NOT EXISTS idx_posts_slug;
+DROP INDEX IF EXISTS idx_posts_slug;
 ALTER TABLE posts DROP COLUMN slug;
 -- +goose StatementEnd
\ No newline at end of file
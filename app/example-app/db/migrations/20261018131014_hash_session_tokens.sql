@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Session IDs are now the SHA-256 hash of the cookie token. Existing rows
+-- hold raw tokens, which SQLite can't hash, so this deletes every session:
+-- all users are logged out once and have to log in again.
+DELETE FROM sessions;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Hashed IDs can't be turned back into tokens, so rolling back logs
+-- everyone out as well. The deleted sessions aren't restored.
+DELETE FROM sessions;
+-- +goose StatementEnd
@@ -9,6 +9,17 @@ import (
 	"time"
 )
 
+type FeatureFlag struct {
+	Key            string    `json:"key"`
+	Description    string    `json:"description"`
+	Enabled        bool      `json:"enabled"`
+	RolloutPercent int64     `json:"rollout_percent"`
+	Roles          string    `json:"roles"`
+	UserIds        string    `json:"user_ids"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
 type Post struct {
 	ID        int64     `json:"id"`
 	Title     string    `json:"title"`
@@ -26,6 +37,9 @@ type Session struct {
 	CreatedAt      time.Time `json:"created_at"`
 	ExpiresAt      time.Time `json:"expires_at"`
 	LastAccessedAt time.Time `json:"last_accessed_at"`
+	UserAgent      string    `json:"user_agent"`
+	IpAddress      string    `json:"ip_address"`
+	Device         string    `json:"device"`
 }
 
 type User struct {
@@ -17,23 +17,30 @@ type Querier interface {
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteExpiredSessions(ctx context.Context) error
+	DeleteFeatureFlag(ctx context.Context, key string) error
 	DeletePost(ctx context.Context, id int64) error
 	DeleteSession(ctx context.Context, id string) error
+	DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) error
 	DeleteUserSessions(ctx context.Context, userID string) error
 	GetByConfirmEmailToken(ctx context.Context, confirmemailtoken sql.NullString) (User, error)
+	GetFeatureFlag(ctx context.Context, key string) (FeatureFlag, error)
 	GetPost(ctx context.Context, id int64) (Post, error)
 	GetPostBySlug(ctx context.Context, slug string) (Post, error)
 	GetSession(ctx context.Context, id string) (Session, error)
+	GetSessionWithUser(ctx context.Context, id string) (GetSessionWithUserRow, error)
 	GetUser(ctx context.Context, id string) (User, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserSessions(ctx context.Context, userID string) ([]Session, error)
+	ListFeatureFlags(ctx context.Context) ([]FeatureFlag, error)
 	ListPosts(ctx context.Context, arg ListPostsParams) ([]Post, error)
 	ListPublishedPosts(ctx context.Context, arg ListPublishedPostsParams) ([]Post, error)
 	PublishPost(ctx context.Context, id int64) error
 	UnpublishPost(ctx context.Context, id int64) error
 	UpdatePost(ctx context.Context, arg UpdatePostParams) (Post, error)
-	UpdateSessionAccess(ctx context.Context, id string) error
+	UpdateSessionAccess(ctx context.Context, arg UpdateSessionAccessParams) error
 	UpdateUser(ctx context.Context, arg UpdateUserParams) error
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) error
+	UpsertFeatureFlag(ctx context.Context, arg UpsertFeatureFlagParams) (FeatureFlag, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,24 @@
+-- name: ListFeatureFlags :many
+SELECT * FROM feature_flags
+ORDER BY key;
+
+-- name: GetFeatureFlag :one
+SELECT * FROM feature_flags
+WHERE key = ?
+LIMIT 1;
+
+-- name: UpsertFeatureFlag :one
+INSERT INTO feature_flags (key, description, enabled, rollout_percent, roles, user_ids)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (key) DO UPDATE SET
+    description = excluded.description,
+    enabled = excluded.enabled,
+    rollout_percent = excluded.rollout_percent,
+    roles = excluded.roles,
+    user_ids = excluded.user_ids,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteFeatureFlag :exec
+DELETE FROM feature_flags
+WHERE key = ?;
@@ -1,6 +1,6 @@
 -- name: CreateSession :one
-INSERT INTO sessions (id, user_id, expires_at)
-VALUES (?, ?, ?)
+INSERT INTO sessions (id, user_id, created_at, expires_at, last_accessed_at, user_agent, ip_address, device)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetSession :one
@@ -17,7 +17,8 @@ ORDER BY last_accessed_at DESC;
 
 -- name: UpdateSessionAccess :exec
 UPDATE sessions
-SET last_accessed_at = CURRENT_TIMESTAMP
+SET last_accessed_at = ?,
+    expires_at = ?
 WHERE id = ?;
 
 -- name: DeleteSession :exec
@@ -32,3 +33,18 @@ WHERE expires_at <= CURRENT_TIMESTAMP;
 DELETE FROM sessions
 WHERE user_id = ?;
 
+-- name: DeleteUserSession :exec
+DELETE FROM sessions
+WHERE id = ?
+AND user_id = ?;
+
+-- name: GetSessionWithUser :one
+SELECT sessions.id, sessions.user_id, sessions.created_at, sessions.expires_at, sessions.last_accessed_at,
+       sessions.user_agent, sessions.ip_address, sessions.device,
+       users.email, users.name, users.role
+FROM sessions
+JOIN users ON users.id = sessions.user_id
+WHERE sessions.id = ?
+AND sessions.expires_at > CURRENT_TIMESTAMP
+LIMIT 1;
+
@@ -36,3 +36,9 @@ SET confirmedat = CURRENT_TIMESTAMP,
     confirmEmailTokenExpiresAt = NULL,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = ?;
+
+-- name: UpdateUserRole :exec
+UPDATE users
+SET role = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
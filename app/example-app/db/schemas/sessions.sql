@@ -1,5 +1,6 @@
 -- Sessions table schema
--- Stores user session data for authentication
+-- Stores user session data for authentication. The id is the SHA-256 hash
+-- of the cookie token, so the table holds no usable tokens.
 
 CREATE TABLE IF NOT EXISTS sessions (
     id TEXT PRIMARY KEY NOT NULL UNIQUE,
@@ -7,6 +8,10 @@ CREATE TABLE IF NOT EXISTS sessions (
     created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     expires_at DATETIME NOT NULL,
     last_accessed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Device info shown on /settings/sessions
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    device TEXT NOT NULL DEFAULT '',
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
@@ -11,19 +11,33 @@ import (
 )
 
 const createSession = `-- name: CreateSession :one
-INSERT INTO sessions (id, user_id, expires_at)
-VALUES (?, ?, ?)
-RETURNING id, user_id, created_at, expires_at, last_accessed_at
+INSERT INTO sessions (id, user_id, created_at, expires_at, last_accessed_at, user_agent, ip_address, device)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, user_id, created_at, expires_at, last_accessed_at, user_agent, ip_address, device
 `
 
 type CreateSessionParams struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	ExpiresAt time.Time `json:"expires_at"`
+	ID             string    `json:"id"`
+	UserID         string    `json:"user_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	LastAccessedAt time.Time `json:"last_accessed_at"`
+	UserAgent      string    `json:"user_agent"`
+	IpAddress      string    `json:"ip_address"`
+	Device         string    `json:"device"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
-	row := q.db.QueryRowContext(ctx, createSession, arg.ID, arg.UserID, arg.ExpiresAt)
+	row := q.db.QueryRowContext(ctx, createSession,
+		arg.ID,
+		arg.UserID,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+		arg.LastAccessedAt,
+		arg.UserAgent,
+		arg.IpAddress,
+		arg.Device,
+	)
 	var i Session
 	err := row.Scan(
 		&i.ID,
@@ -31,6 +45,9 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.CreatedAt,
 		&i.ExpiresAt,
 		&i.LastAccessedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.Device,
 	)
 	return i, err
 }
@@ -55,6 +72,22 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteUserSession = `-- name: DeleteUserSession :exec
+DELETE FROM sessions
+WHERE id = ?
+AND user_id = ?
+`
+
+type DeleteUserSessionParams struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+func (q *Queries) DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) error {
+	_, err := q.db.ExecContext(ctx, deleteUserSession, arg.ID, arg.UserID)
+	return err
+}
+
 const deleteUserSessions = `-- name: DeleteUserSessions :exec
 DELETE FROM sessions
 WHERE user_id = ?
@@ -66,7 +99,7 @@ func (q *Queries) DeleteUserSessions(ctx context.Context, userID string) error {
 }
 
 const getSession = `-- name: GetSession :one
-SELECT id, user_id, created_at, expires_at, last_accessed_at FROM sessions
+SELECT id, user_id, created_at, expires_at, last_accessed_at, user_agent, ip_address, device FROM sessions
 WHERE id = ?
 AND expires_at > CURRENT_TIMESTAMP
 LIMIT 1
@@ -81,12 +114,59 @@ func (q *Queries) GetSession(ctx context.Context, id string) (Session, error) {
 		&i.CreatedAt,
 		&i.ExpiresAt,
 		&i.LastAccessedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.Device,
+	)
+	return i, err
+}
+
+const getSessionWithUser = `-- name: GetSessionWithUser :one
+SELECT sessions.id, sessions.user_id, sessions.created_at, sessions.expires_at, sessions.last_accessed_at,
+       sessions.user_agent, sessions.ip_address, sessions.device,
+       users.email, users.name, users.role
+FROM sessions
+JOIN users ON users.id = sessions.user_id
+WHERE sessions.id = ?
+AND sessions.expires_at > CURRENT_TIMESTAMP
+LIMIT 1
+`
+
+type GetSessionWithUserRow struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"user_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	LastAccessedAt time.Time `json:"last_accessed_at"`
+	UserAgent      string    `json:"user_agent"`
+	IpAddress      string    `json:"ip_address"`
+	Device         string    `json:"device"`
+	Email          string    `json:"email"`
+	Name           string    `json:"name"`
+	Role           string    `json:"role"`
+}
+
+func (q *Queries) GetSessionWithUser(ctx context.Context, id string) (GetSessionWithUserRow, error) {
+	row := q.db.QueryRowContext(ctx, getSessionWithUser, id)
+	var i GetSessionWithUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LastAccessedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.Device,
+		&i.Email,
+		&i.Name,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUserSessions = `-- name: GetUserSessions :many
-SELECT id, user_id, created_at, expires_at, last_accessed_at FROM sessions
+SELECT id, user_id, created_at, expires_at, last_accessed_at, user_agent, ip_address, device FROM sessions
 WHERE user_id = ?
 AND expires_at > CURRENT_TIMESTAMP
 ORDER BY last_accessed_at DESC
@@ -107,6 +187,9 @@ func (q *Queries) GetUserSessions(ctx context.Context, userID string) ([]Session
 			&i.CreatedAt,
 			&i.ExpiresAt,
 			&i.LastAccessedAt,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.Device,
 		); err != nil {
 			return nil, err
 		}
@@ -123,11 +206,18 @@ func (q *Queries) GetUserSessions(ctx context.Context, userID string) ([]Session
 
 const updateSessionAccess = `-- name: UpdateSessionAccess :exec
 UPDATE sessions
-SET last_accessed_at = CURRENT_TIMESTAMP
+SET last_accessed_at = ?,
+    expires_at = ?
 WHERE id = ?
 `
 
-func (q *Queries) UpdateSessionAccess(ctx context.Context, id string) error {
-	_, err := q.db.ExecContext(ctx, updateSessionAccess, id)
+type UpdateSessionAccessParams struct {
+	LastAccessedAt time.Time `json:"last_accessed_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	ID             string    `json:"id"`
+}
+
+func (q *Queries) UpdateSessionAccess(ctx context.Context, arg UpdateSessionAccessParams) error {
+	_, err := q.db.ExecContext(ctx, updateSessionAccess, arg.LastAccessedAt, arg.ExpiresAt, arg.ID)
 	return err
 }
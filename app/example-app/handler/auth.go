@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"net/http"
@@ -8,7 +9,7 @@ import (
 	"time"
 
 	"github.com/nick-friedrich/beesting/app/example-app/db"
-	"github.com/nick-friedrich/beesting/app/example-app/pkg/config"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/deps"
 	"github.com/nick-friedrich/beesting/app/example-app/pkg/mail"
 	passwordPkg "github.com/nick-friedrich/beesting/app/example-app/pkg/password"
 	"github.com/nick-friedrich/beesting/app/example-app/pkg/session"
@@ -21,10 +22,10 @@ import (
 
 // Legacy validation functions removed - now using validator v10 struct-based validation
 
-func sendConfirmationEmail(user *db.User) error {
-	config := config.GetConfig()
-	mailer := mail.GetMailer()
-	err := mailer.SendEmail(&mail.Email{
+// SendConfirmationEmail mails the user a link to confirm their email address
+func SendConfirmationEmail(d *deps.Deps, user *db.User) error {
+	config := d.Config.Current()
+	err := d.Mailer.SendEmail(&mail.Email{
 		From:    fmt.Sprintf("%s <%s>", config.EmailConfig.Name, config.EmailConfig.From),
 		To:      user.Email,
 		Subject: "Confirm your email",
@@ -42,9 +43,32 @@ Please click the link to confirm your email:
 	return nil
 }
 
-func LoginHandler() http.HandlerFunc {
+// RenewConfirmEmailToken gives the user a new confirmation token that is
+// valid for 24 hours and stores it
+func RenewConfirmEmailToken(ctx context.Context, d *deps.Deps, user *db.User) error {
+	token := sql.NullString{String: ulid.Make().String(), Valid: true}
+	expiresAt := sql.NullTime{Time: time.Now().Add(time.Hour * 24), Valid: true}
+
+	err := d.Queries.UpdateUser(ctx, db.UpdateUserParams{
+		ID:                         user.ID,
+		Name:                       user.Name,
+		Email:                      user.Email,
+		PasswordHash:               user.PasswordHash,
+		Confirmemailtoken:          token,
+		Confirmemailtokenexpiresat: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	user.Confirmemailtoken = token
+	user.Confirmemailtokenexpiresat = expiresAt
+	return nil
+}
+
+func LoginHandler(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sessionData, _ := session.Default.GetSession(r)
+		sessionData := session.FromContext(r.Context())
 
 		if sessionData.LoggedIn {
 			http.Redirect(w, r, "/", http.StatusSeeOther)
@@ -84,9 +108,9 @@ func LoginHandler() http.HandlerFunc {
 	}
 }
 
-func RegisterHandler() http.HandlerFunc {
+func RegisterHandler(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sessionData, _ := session.Default.GetSession(r)
+		sessionData := session.FromContext(r.Context())
 
 		if sessionData.LoggedIn {
 			http.Redirect(w, r, "/", http.StatusSeeOther)
@@ -108,9 +132,9 @@ func RegisterHandler() http.HandlerFunc {
 	}
 }
 
-func LoginSubmitHandler(q *db.Queries) http.HandlerFunc {
+func LoginSubmitHandler(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sessionData, _ := session.Default.GetSession(r)
+		sessionData := session.FromContext(r.Context())
 
 		if sessionData.LoggedIn {
 			http.Redirect(w, r, "/", http.StatusSeeOther)
@@ -124,7 +148,7 @@ func LoginSubmitHandler(q *db.Queries) http.HandlerFunc {
 		}
 
 		// Validate using struct tags
-		if err := validation.ValidateLoginForm(form); err != nil {
+		if err := d.Validator.ValidateLoginForm(form); err != nil {
 			errors := validation.ConvertValidationErrors(err)
 			views.Layout(
 				authviews.Login(authviews.LoginProps{
@@ -142,10 +166,10 @@ func LoginSubmitHandler(q *db.Queries) http.HandlerFunc {
 		}
 
 		// Authenticate user
-		user, err := q.GetUserByEmail(r.Context(), form.Email)
+		user, err := d.Queries.GetUserByEmail(r.Context(), form.Email)
 		if err != nil {
 			// User not found or database error
-			fmt.Printf("Login attempt failed: email=%s, error=%v\n", form.Email, err)
+			d.Logger.Info("login failed", "email", form.Email, "err", err)
 			views.Layout(
 				authviews.Login(authviews.LoginProps{
 					Errors:                types.AuthValidationErrors{General: "Invalid email or password"},
@@ -165,7 +189,7 @@ func LoginSubmitHandler(q *db.Queries) http.HandlerFunc {
 		// Verify password using Argon2
 		passwordMatch, err := passwordPkg.VerifyPassword(form.Password, user.PasswordHash)
 		if err != nil {
-			fmt.Printf("Password verification error: %v\n", err)
+			d.Logger.Error("password verification failed", "err", err)
 			views.Layout(
 				authviews.Login(authviews.LoginProps{
 					Errors: types.AuthValidationErrors{
@@ -185,7 +209,7 @@ func LoginSubmitHandler(q *db.Queries) http.HandlerFunc {
 		}
 
 		if !passwordMatch {
-			fmt.Printf("Login attempt failed: email=%s, invalid password\n", form.Email)
+			d.Logger.Info("login failed: invalid password", "email", form.Email)
 			views.Layout(
 				authviews.Login(authviews.LoginProps{
 					Errors: types.AuthValidationErrors{
@@ -205,7 +229,7 @@ func LoginSubmitHandler(q *db.Queries) http.HandlerFunc {
 		}
 
 		// Get config and check if verified if enabled
-		config := config.GetConfig()
+		config := d.Config.Current()
 		if config.AuthConfig.ConfirmEmail && !user.Confirmedat.Valid {
 			views.Layout(
 				authviews.Login(authviews.LoginProps{
@@ -222,11 +246,12 @@ func LoginSubmitHandler(q *db.Queries) http.HandlerFunc {
 			return
 		}
 
-		// Login successful - create session
-		err = session.Default.SetSession(w, user.ID, user.Email, user.Name)
+		// Login successful - replace any session the browser brought along,
+		// so a planted session ID can't be used to hijack this login
+		err = d.Sessions.Rotate(w, r, user)
 		if err != nil {
-			fmt.Printf("Session creation error: %v\n", err)
-			sessionData, _ := session.Default.GetSession(r)
+			d.Logger.Error("failed to create session", "err", err)
+			sessionData := session.FromContext(r.Context())
 
 			views.Layout(
 				authviews.Login(authviews.LoginProps{
@@ -244,14 +269,14 @@ func LoginSubmitHandler(q *db.Queries) http.HandlerFunc {
 			return
 		}
 
-		fmt.Printf("Login successful: user_id=%s, email=%s\n", user.ID, form.Email)
+		d.Logger.Info("login successful", "user_id", user.ID, "email", form.Email)
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 	}
 }
 
-func RegisterSubmitHandler(q *db.Queries) http.HandlerFunc {
+func RegisterSubmitHandler(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sessionData, _ := session.Default.GetSession(r)
+		sessionData := session.FromContext(r.Context())
 
 		if sessionData.LoggedIn {
 			http.Redirect(w, r, "/", http.StatusSeeOther)
@@ -267,7 +292,7 @@ func RegisterSubmitHandler(q *db.Queries) http.HandlerFunc {
 		}
 
 		// Validate using struct tags
-		if err := validation.ValidateRegisterForm(form); err != nil {
+		if err := d.Validator.ValidateRegisterForm(form); err != nil {
 			errors := validation.ConvertValidationErrors(err)
 			views.Layout(
 				authviews.Register(authviews.RegisterProps{
@@ -285,7 +310,7 @@ func RegisterSubmitHandler(q *db.Queries) http.HandlerFunc {
 		// Hash the password using Argon2
 		passwordHash, err := passwordPkg.HashPassword(form.Password)
 		if err != nil {
-			fmt.Printf("Password hashing error: %v\n", err)
+			d.Logger.Error("failed to hash password", "err", err)
 			views.Layout(
 				authviews.Register(authviews.RegisterProps{
 					Errors:  types.AuthValidationErrors{General: "Registration error. Please try again."},
@@ -301,14 +326,14 @@ func RegisterSubmitHandler(q *db.Queries) http.HandlerFunc {
 
 		var confirmEmailToken string
 		var confirmEmailTokenExpiresAt time.Time
-		config := config.GetConfig()
+		config := d.Config.Current()
 		if config.AuthConfig.ConfirmEmail {
 			confirmEmailToken = ulid.Make().String()
 			confirmEmailTokenExpiresAt = time.Now().Add(time.Hour * 24)
 		}
 
 		// Create user with hashed password
-		user, err := q.CreateUser(r.Context(), db.CreateUserParams{
+		user, err := d.Queries.CreateUser(r.Context(), db.CreateUserParams{
 			ID:                         ulid.Make().String(),
 			Name:                       form.Name,
 			Email:                      form.Email,
@@ -319,7 +344,7 @@ func RegisterSubmitHandler(q *db.Queries) http.HandlerFunc {
 
 		// Error handling
 		if err != nil {
-			fmt.Printf("User creation error: %v\n", err)
+			d.Logger.Error("failed to create user", "err", err)
 			// Check if it's a unique constraint violation on email
 			if strings.Contains(err.Error(), "UNIQUE constraint failed") && strings.Contains(err.Error(), "email") {
 				views.Layout(
@@ -348,12 +373,12 @@ func RegisterSubmitHandler(q *db.Queries) http.HandlerFunc {
 		}
 
 		// Send confirmation email
-		err = sendConfirmationEmail(&user)
+		err = SendConfirmationEmail(d, &user)
 		if err != nil {
-			fmt.Printf("Confirmation email error: %v\n", err)
+			d.Logger.Error("failed to send confirmation email", "err", err)
 		}
 
-		fmt.Printf("User created: %+v\n", user)
+		d.Logger.Info("user created", "user_id", user.ID, "email", user.Email)
 
 		// Build URL with config
 		if config.AuthConfig.ConfirmEmail {
@@ -364,7 +389,7 @@ func RegisterSubmitHandler(q *db.Queries) http.HandlerFunc {
 	}
 }
 
-func VerifyEmailHandler(q *db.Queries) http.HandlerFunc {
+func VerifyEmailHandler(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		token := r.URL.Query().Get("token")
 		if token == "" {
@@ -372,7 +397,7 @@ func VerifyEmailHandler(q *db.Queries) http.HandlerFunc {
 			return
 		}
 
-		user, err := q.GetByConfirmEmailToken(r.Context(), sql.NullString{String: token, Valid: true})
+		user, err := d.Queries.GetByConfirmEmailToken(r.Context(), sql.NullString{String: token, Valid: true})
 		if err != nil {
 			http.Error(w, "Invalid token", http.StatusBadRequest)
 			return
@@ -391,7 +416,7 @@ func VerifyEmailHandler(q *db.Queries) http.HandlerFunc {
 		}
 
 		// Confirm the user's email
-		err = q.ConfirmUserEmail(r.Context(), user.ID)
+		err = d.Queries.ConfirmUserEmail(r.Context(), user.ID)
 		if err != nil {
 			http.Error(w, "Failed to confirm email", http.StatusInternalServerError)
 			return
@@ -402,9 +427,9 @@ func VerifyEmailHandler(q *db.Queries) http.HandlerFunc {
 	}
 }
 
-func ResendConfirmationEmailHandler(q *db.Queries) http.HandlerFunc {
+func ResendConfirmationEmailHandler(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sessionData, _ := session.Default.GetSession(r)
+		sessionData := session.FromContext(r.Context())
 
 		// Don't allow resending if already logged in
 		if sessionData.LoggedIn {
@@ -431,7 +456,7 @@ func ResendConfirmationEmailHandler(q *db.Queries) http.HandlerFunc {
 		}
 
 		// Get user by email
-		user, err := q.GetUserByEmail(r.Context(), email)
+		user, err := d.Queries.GetUserByEmail(r.Context(), email)
 		if err != nil {
 			// Don't reveal if user exists or not - just show success message
 			http.Redirect(w, r, "/login?emailSent=true", http.StatusSeeOther)
@@ -456,20 +481,9 @@ func ResendConfirmationEmailHandler(q *db.Queries) http.HandlerFunc {
 		}
 
 		// Generate new confirmation token
-		confirmEmailToken := ulid.Make().String()
-		confirmEmailTokenExpiresAt := time.Now().Add(time.Hour * 24)
-
-		// Update user with new token
-		err = q.UpdateUser(r.Context(), db.UpdateUserParams{
-			ID:                         user.ID,
-			Name:                       user.Name,
-			Email:                      user.Email,
-			PasswordHash:               user.PasswordHash,
-			Confirmemailtoken:          sql.NullString{String: confirmEmailToken, Valid: true},
-			Confirmemailtokenexpiresat: sql.NullTime{Time: confirmEmailTokenExpiresAt, Valid: true},
-		})
+		err = RenewConfirmEmailToken(r.Context(), d, &user)
 		if err != nil {
-			fmt.Printf("Failed to update user with new token: %v\n", err)
+			d.Logger.Error("failed to renew confirmation token", "err", err)
 			views.Layout(
 				authviews.Login(authviews.LoginProps{
 					Errors:                types.AuthValidationErrors{General: "Failed to send confirmation email. Please try again."},
@@ -485,14 +499,10 @@ func ResendConfirmationEmailHandler(q *db.Queries) http.HandlerFunc {
 			return
 		}
 
-		// Update user struct with new token for email sending
-		user.Confirmemailtoken = sql.NullString{String: confirmEmailToken, Valid: true}
-		user.Confirmemailtokenexpiresat = sql.NullTime{Time: confirmEmailTokenExpiresAt, Valid: true}
-
 		// Send confirmation email
-		err = sendConfirmationEmail(&user)
+		err = SendConfirmationEmail(d, &user)
 		if err != nil {
-			fmt.Printf("Confirmation email error: %v\n", err)
+			d.Logger.Error("failed to send confirmation email", "err", err)
 			// Still redirect to success to avoid revealing if user exists
 		}
 
@@ -501,14 +511,14 @@ func ResendConfirmationEmailHandler(q *db.Queries) http.HandlerFunc {
 	}
 }
 
-func LogoutHandler() http.HandlerFunc {
+func LogoutHandler(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		err := session.Default.ClearSession(w, r)
+		err := d.Sessions.ClearSession(w, r)
 		if err != nil {
-			fmt.Printf("Logout error: %v\n", err)
+			d.Logger.Error("failed to clear session", "err", err)
 		}
 
-		fmt.Printf("User logged out\n")
+		d.Logger.Info("user logged out")
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 	}
 }
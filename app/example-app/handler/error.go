@@ -3,13 +3,14 @@ package handler
 import (
 	"net/http"
 
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/deps"
 	"github.com/nick-friedrich/beesting/app/example-app/pkg/session"
 	"github.com/nick-friedrich/beesting/app/example-app/views"
 )
 
-func Error(error string) http.HandlerFunc {
+func Error(d *deps.Deps, error string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sessionData, _ := session.Default.GetSession(r)
+		sessionData := session.FromContext(r.Context())
 
 		w.WriteHeader(http.StatusInternalServerError)
 		views.Layout(
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nick-friedrich/beesting/app/example-app/db"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/deps"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/flags"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/session"
+	"github.com/nick-friedrich/beesting/app/example-app/views"
+	adminviews "github.com/nick-friedrich/beesting/app/example-app/views/admin"
+)
+
+// renderFlags renders the flag list with form filled into the edit form
+func renderFlags(d *deps.Deps, w http.ResponseWriter, r *http.Request, form db.FeatureFlag, errorMsg string) {
+	sessionData := session.FromContext(r.Context())
+
+	list, err := d.Queries.ListFeatureFlags(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if errorMsg != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	views.Layout(adminviews.Flags(list, form, errorMsg, r), sessionData, "Feature Flags").Render(r.Context(), w)
+}
+
+func ShowFlags(d *deps.Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// ?key= loads an existing flag into the form
+		form := db.FeatureFlag{Enabled: true, RolloutPercent: 100}
+		if key := r.URL.Query().Get("key"); key != "" {
+			flag, err := d.Queries.GetFeatureFlag(r.Context(), key)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if err == nil {
+				form = flag
+			}
+		}
+
+		renderFlags(d, w, r, form, "")
+	}
+}
+
+func SaveFlag(d *deps.Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionData := session.FromContext(r.Context())
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		form := db.FeatureFlag{
+			Key:         strings.TrimSpace(r.FormValue("key")),
+			Description: strings.TrimSpace(r.FormValue("description")),
+			Enabled:     r.FormValue("enabled") == "on",
+			Roles:       flags.NormalizeList(r.FormValue("roles")),
+			UserIds:     flags.NormalizeList(r.FormValue("user_ids")),
+		}
+
+		if err := flags.ValidateKey(form.Key); err != nil {
+			renderFlags(d, w, r, form, err.Error())
+			return
+		}
+		percent, err := strconv.ParseInt(r.FormValue("rollout_percent"), 10, 64)
+		if err != nil || percent < 0 || percent > 100 {
+			renderFlags(d, w, r, form, "rollout percent must be a number from 0 to 100")
+			return
+		}
+		form.RolloutPercent = percent
+
+		_, err = d.Queries.UpsertFeatureFlag(r.Context(), db.UpsertFeatureFlagParams{
+			Key:            form.Key,
+			Description:    form.Description,
+			Enabled:        form.Enabled,
+			RolloutPercent: form.RolloutPercent,
+			Roles:          form.Roles,
+			UserIds:        form.UserIds,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		d.Logger.Info("feature flag saved", "key", form.Key, "enabled", form.Enabled, "by", sessionData.Email)
+		http.Redirect(w, r, "/admin/flags", http.StatusSeeOther)
+	}
+}
+
+func ToggleFlag(d *deps.Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionData := session.FromContext(r.Context())
+
+		flag, err := d.Queries.GetFeatureFlag(r.Context(), chi.URLParam(r, "key"))
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Feature flag not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		_, err = d.Queries.UpsertFeatureFlag(r.Context(), db.UpsertFeatureFlagParams{
+			Key:            flag.Key,
+			Description:    flag.Description,
+			Enabled:        !flag.Enabled,
+			RolloutPercent: flag.RolloutPercent,
+			Roles:          flag.Roles,
+			UserIds:        flag.UserIds,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		d.Logger.Info("feature flag toggled", "key", flag.Key, "enabled", !flag.Enabled, "by", sessionData.Email)
+		http.Redirect(w, r, "/admin/flags", http.StatusSeeOther)
+	}
+}
+
+func DeleteFlag(d *deps.Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionData := session.FromContext(r.Context())
+
+		key := chi.URLParam(r, "key")
+		if err := d.Queries.DeleteFeatureFlag(r.Context(), key); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		d.Logger.Info("feature flag deleted", "key", key, "by", sessionData.Email)
+		http.Redirect(w, r, "/admin/flags", http.StatusSeeOther)
+	}
+}
@@ -3,13 +3,14 @@ package handler
 import (
 	"net/http"
 
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/deps"
 	"github.com/nick-friedrich/beesting/app/example-app/pkg/session"
 	"github.com/nick-friedrich/beesting/app/example-app/views"
 )
 
-func Home() http.HandlerFunc {
+func Home(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sessionData, _ := session.Default.GetSession(r)
+		sessionData := session.FromContext(r.Context())
 
 		views.Layout(
 			views.Home(sessionData),
@@ -7,15 +7,16 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/nick-friedrich/beesting/app/example-app/db"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/deps"
 	"github.com/nick-friedrich/beesting/app/example-app/pkg/session"
 	"github.com/nick-friedrich/beesting/app/example-app/pkg/slug"
 	"github.com/nick-friedrich/beesting/app/example-app/views"
 	postviews "github.com/nick-friedrich/beesting/app/example-app/views/posts"
 )
 
-func ShowPosts(q *db.Queries) http.HandlerFunc {
+func ShowPosts(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		posts, err := q.ListPosts(r.Context(), db.ListPostsParams{
+		posts, err := d.Queries.ListPosts(r.Context(), db.ListPostsParams{
 			Limit:  10,
 			Offset: 0,
 		})
@@ -25,44 +26,34 @@ func ShowPosts(q *db.Queries) http.HandlerFunc {
 			return
 		}
 
-		sessionData, _ := session.Default.GetSession(r)
+		sessionData := session.FromContext(r.Context())
 		views.Layout(postviews.Index(posts, sessionData, r), sessionData, "Posts").Render(r.Context(), w)
 	}
 }
 
-func ShowPost(q *db.Queries) http.HandlerFunc {
+func ShowPost(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		post, err := q.GetPostBySlug(r.Context(), chi.URLParam(r, "slug"))
+		post, err := d.Queries.GetPostBySlug(r.Context(), chi.URLParam(r, "slug"))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		sessionData, _ := session.Default.GetSession(r)
+		sessionData := session.FromContext(r.Context())
 		views.Layout(postviews.Show(post, sessionData, r), sessionData, "Post").Render(r.Context(), w)
 	}
 }
 
-func CreatePostShow() http.HandlerFunc {
+func CreatePostShow(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sessionData, _ := session.Default.GetSession(r)
-		if sessionData.UserRole != "admin" {
-			Error("You're not authorized to create posts")(w, r)
-			return
-		}
+		sessionData := session.FromContext(r.Context())
 
 		views.Layout(postviews.New(r), sessionData, "New Post").Render(r.Context(), w)
 	}
 }
 
-func CreatePostSubmit(q *db.Queries) http.HandlerFunc {
+func CreatePostSubmit(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sessionData, _ := session.Default.GetSession(r)
-		if sessionData.UserRole != "admin" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-
 		// Parse form data
 		if err := r.ParseForm(); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -87,13 +78,13 @@ func CreatePostSubmit(q *db.Queries) http.HandlerFunc {
 		}
 
 		// Check if slug already exists
-		existingPost, err := q.GetPostBySlug(r.Context(), slugValue)
+		existingPost, err := d.Queries.GetPostBySlug(r.Context(), slugValue)
 		if err == nil && existingPost.ID != 0 {
 			http.Error(w, "A post with this slug already exists", http.StatusBadRequest)
 			return
 		}
 
-		post, err := q.CreatePost(r.Context(), db.CreatePostParams{
+		post, err := d.Queries.CreatePost(r.Context(), db.CreatePostParams{
 			Title:     title,
 			Slug:      slugValue,
 			Content:   content,
@@ -109,13 +100,9 @@ func CreatePostSubmit(q *db.Queries) http.HandlerFunc {
 	}
 }
 
-func EditPostShow(q *db.Queries) http.HandlerFunc {
+func EditPostShow(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sessionData, _ := session.Default.GetSession(r)
-		if sessionData.UserRole != "admin" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
+		sessionData := session.FromContext(r.Context())
 
 		postIDStr := chi.URLParam(r, "id")
 		postID, err := strconv.ParseInt(postIDStr, 10, 64)
@@ -124,7 +111,7 @@ func EditPostShow(q *db.Queries) http.HandlerFunc {
 			return
 		}
 
-		post, err := q.GetPost(r.Context(), postID)
+		post, err := d.Queries.GetPost(r.Context(), postID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -134,14 +121,8 @@ func EditPostShow(q *db.Queries) http.HandlerFunc {
 	}
 }
 
-func EditPostSubmit(q *db.Queries) http.HandlerFunc {
+func EditPostSubmit(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sessionData, _ := session.Default.GetSession(r)
-		if sessionData.UserRole != "admin" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-
 		postIDStr := chi.URLParam(r, "id")
 		postID, err := strconv.ParseInt(postIDStr, 10, 64)
 		if err != nil {
@@ -173,13 +154,13 @@ func EditPostSubmit(q *db.Queries) http.HandlerFunc {
 		}
 
 		// Check if slug already exists (excluding current post)
-		existingPost, err := q.GetPostBySlug(r.Context(), slugValue)
+		existingPost, err := d.Queries.GetPostBySlug(r.Context(), slugValue)
 		if err == nil && existingPost.ID != 0 && existingPost.ID != postID {
 			http.Error(w, "A post with this slug already exists", http.StatusBadRequest)
 			return
 		}
 
-		post, err := q.UpdatePost(r.Context(), db.UpdatePostParams{
+		post, err := d.Queries.UpdatePost(r.Context(), db.UpdatePostParams{
 			ID:        postID,
 			Title:     title,
 			Slug:      slugValue,
@@ -196,14 +177,8 @@ func EditPostSubmit(q *db.Queries) http.HandlerFunc {
 	}
 }
 
-func DeletePostWeb(q *db.Queries) http.HandlerFunc {
+func DeletePostWeb(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		sessionData, _ := session.Default.GetSession(r)
-		if sessionData.UserRole != "admin" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-
 		postIDStr := chi.URLParam(r, "id")
 		postID, err := strconv.ParseInt(postIDStr, 10, 64)
 		if err != nil {
@@ -211,7 +186,7 @@ func DeletePostWeb(q *db.Queries) http.HandlerFunc {
 			return
 		}
 
-		err = q.DeletePost(r.Context(), postID)
+		err = d.Queries.DeletePost(r.Context(), postID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -8,15 +8,16 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/nick-friedrich/beesting/app/example-app/db"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/deps"
 )
 
 // Handler functions
-func ListPosts(q *db.Queries) http.HandlerFunc {
+func ListPosts(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		limit := int64(10)
 		offset := int64(0)
 
-		posts, err := q.ListPosts(r.Context(), db.ListPostsParams{
+		posts, err := d.Queries.ListPosts(r.Context(), db.ListPostsParams{
 			Limit:  limit,
 			Offset: offset,
 		})
@@ -30,7 +31,7 @@ func ListPosts(q *db.Queries) http.HandlerFunc {
 	}
 }
 
-func CreatePost(q *db.Queries) http.HandlerFunc {
+func CreatePost(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var input struct {
 			Title     string `json:"title"`
@@ -44,7 +45,7 @@ func CreatePost(q *db.Queries) http.HandlerFunc {
 			return
 		}
 
-		post, err := q.CreatePost(r.Context(), db.CreatePostParams{
+		post, err := d.Queries.CreatePost(r.Context(), db.CreatePostParams{
 			Title:     input.Title,
 			Content:   input.Content,
 			Author:    input.Author,
@@ -61,7 +62,7 @@ func CreatePost(q *db.Queries) http.HandlerFunc {
 	}
 }
 
-func GetPost(q *db.Queries) http.HandlerFunc {
+func GetPost(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 		if err != nil {
@@ -69,7 +70,7 @@ func GetPost(q *db.Queries) http.HandlerFunc {
 			return
 		}
 
-		post, err := q.GetPost(r.Context(), id)
+		post, err := d.Queries.GetPost(r.Context(), id)
 		if err == sql.ErrNoRows {
 			http.Error(w, "post not found", http.StatusNotFound)
 			return
@@ -84,7 +85,7 @@ func GetPost(q *db.Queries) http.HandlerFunc {
 	}
 }
 
-func UpdatePost(q *db.Queries) http.HandlerFunc {
+func UpdatePost(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 		if err != nil {
@@ -104,7 +105,7 @@ func UpdatePost(q *db.Queries) http.HandlerFunc {
 			return
 		}
 
-		post, err := q.UpdatePost(r.Context(), db.UpdatePostParams{
+		post, err := d.Queries.UpdatePost(r.Context(), db.UpdatePostParams{
 			Title:     input.Title,
 			Content:   input.Content,
 			Author:    input.Author,
@@ -121,7 +122,7 @@ func UpdatePost(q *db.Queries) http.HandlerFunc {
 	}
 }
 
-func DeletePost(q *db.Queries) http.HandlerFunc {
+func DeletePost(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 		if err != nil {
@@ -129,7 +130,7 @@ func DeletePost(q *db.Queries) http.HandlerFunc {
 			return
 		}
 
-		if err := q.DeletePost(r.Context(), id); err != nil {
+		if err := d.Queries.DeletePost(r.Context(), id); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -138,7 +139,7 @@ func DeletePost(q *db.Queries) http.HandlerFunc {
 	}
 }
 
-func PublishPost(q *db.Queries) http.HandlerFunc {
+func PublishPost(d *deps.Deps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 		if err != nil {
@@ -146,7 +147,7 @@ func PublishPost(q *db.Queries) http.HandlerFunc {
 			return
 		}
 
-		if err := q.PublishPost(r.Context(), id); err != nil {
+		if err := d.Queries.PublishPost(r.Context(), id); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
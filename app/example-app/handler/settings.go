@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/deps"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/session"
+	"github.com/nick-friedrich/beesting/app/example-app/views"
+	settingsviews "github.com/nick-friedrich/beesting/app/example-app/views/settings"
+)
+
+// revokedMessages are shown after a redirect with ?revoked=
+var revokedMessages = map[string]string{
+	"device": "the device was signed out",
+	"others": "all other devices were signed out",
+}
+
+func ShowSessions(d *deps.Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionData := session.FromContext(r.Context())
+
+		notice := ""
+		list, err := d.Sessions.GetUserSessions(sessionData.UserID)
+		if errors.Is(err, session.ErrNotSupported) {
+			notice = "this server keeps sessions in cookies, so signed in devices can't be listed"
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		message := revokedMessages[r.URL.Query().Get("revoked")]
+		views.Layout(settingsviews.Sessions(list, sessionData.SessionID, message, notice, r), sessionData, "Devices").Render(r.Context(), w)
+	}
+}
+
+func RevokeSession(d *deps.Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionData := session.FromContext(r.Context())
+
+		// The current session ends through logout, which also clears the cookie
+		id := chi.URLParam(r, "id")
+		if id == sessionData.SessionID {
+			http.Redirect(w, r, "/logout", http.StatusSeeOther)
+			return
+		}
+
+		err := d.Sessions.RevokeSession(sessionData.UserID, id)
+		if errors.Is(err, session.ErrNotSupported) {
+			http.Error(w, "Sessions can't be revoked with this session store", http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		d.Logger.Info("session revoked", "user", sessionData.Email)
+		http.Redirect(w, r, "/settings/sessions?revoked=device", http.StatusSeeOther)
+	}
+}
+
+func RevokeOtherSessions(d *deps.Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionData := session.FromContext(r.Context())
+
+		err := d.Sessions.RevokeOtherSessions(sessionData.UserID, sessionData.SessionID)
+		if errors.Is(err, session.ErrNotSupported) {
+			http.Error(w, "Sessions can't be revoked with this session store", http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		d.Logger.Info("other sessions revoked", "user", sessionData.Email)
+		http.Redirect(w, r, "/settings/sessions?revoked=others", http.StatusSeeOther)
+	}
+}
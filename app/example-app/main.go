@@ -1,65 +1,64 @@
 package main
 
 import (
-	"crypto/rand"
 	"database/sql"
 	"log"
-	"net/http"
+	"log/slog"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/nick-friedrich/beesting/app/example-app/db"
 	"github.com/nick-friedrich/beesting/app/example-app/pkg/config"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/deps"
 	"github.com/nick-friedrich/beesting/app/example-app/pkg/mail"
-	"github.com/nick-friedrich/beesting/app/example-app/pkg/session"
-	"github.com/nick-friedrich/beesting/app/example-app/pkg/validation"
+	"github.com/nick-friedrich/beesting/pkg/beesting"
 )
 
 func main() {
+	// Load config from the environment, .env and config files
+	cfg, err := config.Load(".")
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// Initialize config
-	config.InitConfig(&config.Config{
-		BaseURL: "http://localhost:3000",
-		EmailConfig: config.EmailConfig{
-			From: "noreply@beesting.com",
-			Name: "BeeSting",
-		},
-		AuthConfig: config.AuthConfig{
-			ConfirmEmail: true,
-		},
-	})
-
-	// Initialize validator singleton
-	validation.InitValidator()
+	// Reload settings like the mail sender on SIGHUP or config file changes
+	store := config.NewStore(cfg, ".")
+	if stop, err := store.Watch(); err != nil {
+		log.Printf("⚠️  %v; config changes need a restart", err)
+	} else {
+		defer stop()
+	}
 
 	// Initialize database
-	database, err := sql.Open("sqlite3", "./app.db")
+	database, err := sql.Open("sqlite3", cfg.DBFile)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer database.Close()
+	// Every connection to an in-memory database opens its own empty one, so
+	// migrations and queries must share a single connection
+	if strings.Contains(cfg.DBFile, ":memory:") || strings.Contains(cfg.DBFile, "mode=memory") {
+		database.SetMaxOpenConns(1)
+	}
 
 	// Run migrations
 	if err := db.RunMigrations(database); err != nil {
 		log.Fatal(err)
 	}
 
-	// Create queries instance
-	queries := db.New(database)
-
-	// Initialize global session manager
-	session.Default = session.NewSessionManager(queries)
-
-	// Generate CSRF key
-	csrfKey := make([]byte, 32)
-	if _, err := rand.Read(csrfKey); err != nil {
-		log.Fatal("Failed to generate CSRF key:", err)
+	// Dependencies shared by handlers and tasks
+	d, err := deps.New(store, db.New(database), &mail.ConsoleAdapter{}, slog.Default())
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	// Initialize mailer
-	mail.InitMailer(&mail.ConsoleAdapter{})
-
-	r := getRouter(queries, csrfKey)
+	app := beesting.NewApp()
+	if err := registerRoutes(app, d); err != nil {
+		log.Fatal(err)
+	}
+	registerTasks(app, d)
 
-	log.Println("🐝 Server running on http://localhost:3000")
-	http.ListenAndServe(":3000", r)
+	if err := app.Run(":" + cfg.Port); err != nil {
+		log.Fatal(err)
+	}
 }
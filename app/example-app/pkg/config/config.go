@@ -1,45 +1,39 @@
 package config
 
-import "sync"
+import "time"
 
+// Config holds the app's settings. Each setting can come from the env
+// variable in its env tag or the config file key in its yaml tag; see Load.
+// Secrets can also be read from a file named by <env>_FILE. Settings tagged
+// reload:"true" can be changed while the app runs; see Store.
 type Config struct {
-	BaseURL     string
-	EmailConfig EmailConfig
-	AuthConfig  AuthConfig
+	// Env is the profile the config was loaded for, set by APP_ENV
+	Env           string        `yaml:"-" env:"APP_ENV"`
+	Port          string        `yaml:"port" env:"PORT" required:"true"`
+	BaseURL       string        `yaml:"base_url" env:"BASE_URL" required:"true"`
+	DBFile        string        `yaml:"db_file" env:"DB_FILE" required:"true"`
+	EmailConfig   EmailConfig   `yaml:"email"`
+	AuthConfig    AuthConfig    `yaml:"auth"`
+	SessionConfig SessionConfig `yaml:"session"`
+	Secrets       Secrets       `yaml:"secrets"`
 }
 
 type AuthConfig struct {
-	ConfirmEmail bool
+	ConfirmEmail bool `yaml:"confirm_email" env:"AUTH_CONFIRM_EMAIL" reload:"true"`
 }
 
-type EmailConfig struct {
-	From string
-	Name string
+type SessionConfig struct {
+	// Store is where sessions are kept: sqlite, memory or cookie
+	Store string `yaml:"store" env:"SESSION_STORE"`
+	// IdleTimeout ends sessions that weren't used for this long
+	IdleTimeout time.Duration `yaml:"idle_timeout" env:"SESSION_IDLE_TIMEOUT"`
+	// MaxLifetime ends sessions this long after login, however active
+	MaxLifetime time.Duration `yaml:"max_lifetime" env:"SESSION_MAX_LIFETIME"`
+	// TouchInterval is how often a session's expiry is renewed while used
+	TouchInterval time.Duration `yaml:"touch_interval" env:"SESSION_TOUCH_INTERVAL"`
 }
 
-var (
-	configInstance *Config
-	once           sync.Once
-)
-
-func GetConfig() *Config {
-	once.Do(func() {
-		configInstance = &Config{
-			BaseURL: "http://localhost:3000",
-			EmailConfig: EmailConfig{
-				From: "noreply@example.com",
-				Name: "Example",
-			},
-			AuthConfig: AuthConfig{
-				ConfirmEmail: true, // Default value
-			},
-		}
-	})
-	return configInstance
-}
-
-func InitConfig(config *Config) {
-	once.Do(func() {
-		configInstance = config
-	})
+type EmailConfig struct {
+	From string `yaml:"from" env:"MAIL_FROM" required:"true" reload:"true"`
+	Name string `yaml:"name" env:"MAIL_NAME" reload:"true"`
 }
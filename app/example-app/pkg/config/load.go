@@ -0,0 +1,357 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nick-friedrich/beesting/pkg/beesting"
+	"gopkg.in/yaml.v3"
+)
+
+// Profiles selectable with APP_ENV
+const (
+	Development = "development"
+	Test        = "test"
+	Production  = "production"
+)
+
+// profiles holds the defaults of each profile. Production has no defaults
+// for settings that differ per deployment, so they must be configured.
+var profiles = map[string]Config{
+	Development: {
+		Port:          "3000",
+		DBFile:        "./app.db",
+		EmailConfig:   EmailConfig{From: "noreply@beesting.com", Name: "BeeSting"},
+		AuthConfig:    AuthConfig{ConfirmEmail: true},
+		SessionConfig: sessionDefaults("sqlite"),
+	},
+	Test: {
+		Port:          "3000",
+		DBFile:        ":memory:",
+		EmailConfig:   EmailConfig{From: "noreply@beesting.com", Name: "BeeSting"},
+		AuthConfig:    AuthConfig{ConfirmEmail: true},
+		SessionConfig: sessionDefaults("memory"),
+	},
+	Production: {
+		Port:          "3000",
+		DBFile:        "./app.db",
+		EmailConfig:   EmailConfig{Name: "BeeSting"},
+		AuthConfig:    AuthConfig{ConfirmEmail: true},
+		SessionConfig: sessionDefaults("sqlite"),
+	},
+}
+
+// Default session lifetimes of every profile
+const (
+	defaultSessionIdleTimeout   = 7 * 24 * time.Hour
+	defaultSessionMaxLifetime   = 30 * 24 * time.Hour
+	defaultSessionTouchInterval = time.Minute
+)
+
+// sessionDefaults returns the session settings of a profile keeping
+// sessions in store
+func sessionDefaults(store string) SessionConfig {
+	return SessionConfig{
+		Store:         store,
+		IdleTimeout:   defaultSessionIdleTimeout,
+		MaxLifetime:   defaultSessionMaxLifetime,
+		TouchInterval: defaultSessionTouchInterval,
+	}
+}
+
+// MissingKeysError lists every required setting that has no value
+type MissingKeysError struct {
+	Env  string
+	Keys []string
+}
+
+func (e *MissingKeysError) Error() string {
+	return fmt.Sprintf("config: missing required settings for %s: %s", e.Env, strings.Join(e.Keys, ", "))
+}
+
+// Load reads the config for the profile named by APP_ENV (default
+// development) from dir. Later sources override earlier ones:
+//
+//  1. the profile's defaults
+//  2. config.yaml
+//  3. config.<APP_ENV>.yaml
+//  4. secrets.yaml, the key file `beesting new` generates, holding Secrets
+//  5. .env
+//  6. environment variables
+//
+// Empty values are treated as unset. Secrets without an env variable are
+// read from the file named by <env>_FILE, as used by Docker and Kubernetes
+// secret mounts. Outside production, missing keys are generated for the
+// run with a warning. Every missing required setting is reported at once
+// in a *MissingKeysError.
+func Load(dir string) (*Config, error) {
+	return load(dir, nil)
+}
+
+// load is Load; outside production, keys missing from the sources are
+// taken from prev when it is set instead of being generated again
+func load(dir string, prev *Secrets) (*Config, error) {
+	dotenv, err := readDotEnv(filepath.Join(dir, ".env"))
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := func(key string) (string, bool) {
+		if value := os.Getenv(key); value != "" {
+			return value, true
+		}
+		value, ok := dotenv[key]
+		return value, ok && value != ""
+	}
+
+	env := Development
+	if value, ok := lookup("APP_ENV"); ok {
+		env = value
+	}
+	cfg, ok := profiles[env]
+	if !ok {
+		return nil, fmt.Errorf("config: unknown APP_ENV %q, use %s, %s or %s", env, Development, Test, Production)
+	}
+	cfg.Env = env
+
+	for _, name := range []string{"config.yaml", "config." + env + ".yaml"} {
+		if err := loadYAML(filepath.Join(dir, name), &cfg); err != nil {
+			return nil, err
+		}
+	}
+	if err := loadYAML(filepath.Join(dir, "secrets.yaml"), &cfg.Secrets); err != nil {
+		return nil, err
+	}
+
+	if err := applyEnv(reflect.ValueOf(&cfg).Elem(), lookup); err != nil {
+		return nil, err
+	}
+
+	if env != Production {
+		// Outside production the base URL follows the port unless set
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = "http://localhost:" + cfg.Port
+		}
+
+		if prev != nil {
+			cfg.Secrets.inherit(prev)
+		}
+		generated, err := cfg.Secrets.fillEphemeral()
+		if err != nil {
+			return nil, err
+		}
+		if len(generated) > 0 {
+			log.Printf("⚠️  %s not set, generated for this run only; `beesting dev` creates secrets.yaml", strings.Join(generated, " and "))
+		}
+	}
+
+	if missing := missingKeys(reflect.ValueOf(cfg)); len(missing) > 0 {
+		return nil, &MissingKeysError{Env: env, Keys: missing}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// validate checks the values that are set
+func (c *Config) validate() error {
+	baseURL, err := url.Parse(c.BaseURL)
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return fmt.Errorf("config: BASE_URL %q must be an absolute URL like https://example.com", c.BaseURL)
+	}
+	if _, err := strconv.Atoi(c.Port); err != nil {
+		return fmt.Errorf("config: PORT %q must be a number", c.Port)
+	}
+	switch c.SessionConfig.Store {
+	case "sqlite", "memory", "cookie":
+	default:
+		return fmt.Errorf("config: SESSION_STORE %q must be sqlite, memory or cookie", c.SessionConfig.Store)
+	}
+	if err := c.SessionConfig.validate(); err != nil {
+		return err
+	}
+	return c.Secrets.validate()
+}
+
+// validate checks that the session lifetimes fit together
+func (s SessionConfig) validate() error {
+	switch {
+	case s.IdleTimeout <= 0:
+		return fmt.Errorf("config: SESSION_IDLE_TIMEOUT must be positive")
+	case s.MaxLifetime < s.IdleTimeout:
+		return fmt.Errorf("config: SESSION_MAX_LIFETIME %s must be at least SESSION_IDLE_TIMEOUT %s", s.MaxLifetime, s.IdleTimeout)
+	case s.TouchInterval <= 0 || s.TouchInterval >= s.IdleTimeout:
+		return fmt.Errorf("config: SESSION_TOUCH_INTERVAL %s must be positive and shorter than SESSION_IDLE_TIMEOUT %s", s.TouchInterval, s.IdleTimeout)
+	}
+	return nil
+}
+
+// readDotEnv reads a .env file into a map; a missing file is empty
+func readDotEnv(path string) (map[string]string, error) {
+	vars, err := beesting.ReadEnvFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(vars))
+	for _, kv := range vars {
+		key, value, _ := strings.Cut(kv, "=")
+		values[key] = value
+	}
+	return values, nil
+}
+
+// loadYAML decodes a config file over out if the file exists. Unknown
+// keys are errors, so typos don't go unnoticed.
+func loadYAML(path string, out any) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv sets the fields with an env tag from lookup, recursing into
+// nested structs
+func applyEnv(v reflect.Value, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(value, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		raw, ok := lookup(key)
+		if !ok && isSecret(field.Type) {
+			var err error
+			if raw, ok, err = lookupFile(key+"_FILE", lookup); err != nil {
+				return err
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		if field.Type == durationType {
+			d, err := time.ParseDuration(raw)
+			if err != nil {
+				return fmt.Errorf("config: %s %q must be a duration like 30m or 12h", key, raw)
+			}
+			value.SetInt(int64(d))
+			continue
+		}
+
+		switch field.Type.Kind() {
+		case reflect.String:
+			value.SetString(raw)
+		case reflect.Slice:
+			if field.Type.Elem().Kind() != reflect.String {
+				return fmt.Errorf("config: unsupported type %s for %s", field.Type, key)
+			}
+			// Lists are comma or newline separated
+			items := strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == '\n' })
+			list := reflect.MakeSlice(field.Type, 0, len(items))
+			for _, item := range items {
+				if item = strings.TrimSpace(item); item != "" {
+					list = reflect.Append(list, reflect.ValueOf(item).Convert(field.Type.Elem()))
+				}
+			}
+			value.Set(list)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				return fmt.Errorf("config: %s %q must be true or false", key, raw)
+			}
+			value.SetBool(b)
+		case reflect.Int:
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				return fmt.Errorf("config: %s %q must be a number", key, raw)
+			}
+			value.SetInt(int64(n))
+		default:
+			return fmt.Errorf("config: unsupported type %s for %s", field.Type, key)
+		}
+	}
+	return nil
+}
+
+var (
+	secretType   = reflect.TypeOf(Secret(""))
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+func isSecret(t reflect.Type) bool {
+	return t == secretType || (t.Kind() == reflect.Slice && t.Elem() == secretType)
+}
+
+// lookupFile reads the file named by the variable key, trimming the
+// trailing newline most tools write
+func lookupFile(key string, lookup func(string) (string, bool)) (string, bool, error) {
+	path, ok := lookup(key)
+	if !ok {
+		return "", false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("config: failed to read %s: %w", key, err)
+	}
+	value := strings.TrimSpace(string(data))
+	return value, value != "", nil
+}
+
+// missingKeys returns the env names of required fields that are empty
+func missingKeys(v reflect.Value) []string {
+	var missing []string
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			missing = append(missing, missingKeys(value)...)
+			continue
+		}
+		if field.Tag.Get("required") == "true" && value.IsZero() {
+			missing = append(missing, field.Tag.Get("env"))
+		}
+	}
+
+	sort.Strings(missing)
+	return missing
+}
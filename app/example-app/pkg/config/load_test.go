@@ -0,0 +1,425 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testKey returns a valid hex encoded key made of b
+func testKey(b byte) Secret {
+	return Secret(strings.Repeat(string("0123456789abcdef"[b%16]), keyLength*2))
+}
+
+// isolate clears every variable Load reads, so the tests don't depend on
+// the environment they run in
+func isolate(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("APP_ENV", "")
+	var clear func(typ reflect.Type)
+	clear = func(typ reflect.Type) {
+		for i := range typ.NumField() {
+			field := typ.Field(i)
+			if field.Type.Kind() == reflect.Struct {
+				clear(field.Type)
+				continue
+			}
+			if key := field.Tag.Get("env"); key != "" {
+				t.Setenv(key, "")
+				t.Setenv(key+"_FILE", "")
+			}
+		}
+	}
+	clear(reflect.TypeOf(Config{}))
+}
+
+// loadWith writes files to a new directory, sets env and loads the config
+// from the directory. "$DIR" in files and env is replaced with the
+// directory.
+func loadWith(t *testing.T, files, env map[string]string) (*Config, error) {
+	t.Helper()
+
+	isolate(t)
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.ReplaceAll(content, "$DIR", dir)), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for key, value := range env {
+		t.Setenv(key, strings.ReplaceAll(value, "$DIR", dir))
+	}
+
+	// Keep the warnings about generated keys out of the test output
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	return Load(dir)
+}
+
+func TestLoadProfiles(t *testing.T) {
+	tests := []struct {
+		env       string
+		wantDB    string
+		wantStore string
+	}{
+		{"", "./app.db", "sqlite"},
+		{Development, "./app.db", "sqlite"},
+		{Test, ":memory:", "memory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			cfg, err := loadWith(t, nil, map[string]string{"APP_ENV": tt.env})
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantEnv := tt.env
+			if wantEnv == "" {
+				wantEnv = Development
+			}
+			if cfg.Env != wantEnv || cfg.DBFile != tt.wantDB || cfg.SessionConfig.Store != tt.wantStore {
+				t.Errorf("Env, DBFile, Store = %q, %q, %q, want %q, %q, %q",
+					cfg.Env, cfg.DBFile, cfg.SessionConfig.Store, wantEnv, tt.wantDB, tt.wantStore)
+			}
+			if cfg.BaseURL != "http://localhost:3000" {
+				t.Errorf("BaseURL = %q, want it to follow the port", cfg.BaseURL)
+			}
+			if cfg.Secrets.CSRFKey == "" || cfg.Secrets.SessionSecret == "" {
+				t.Error("missing keys were not generated")
+			}
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := loadWith(t, nil, map[string]string{"APP_ENV": "staging"})
+		if err == nil || !strings.Contains(err.Error(), `unknown APP_ENV "staging"`) {
+			t.Errorf("err = %v, want unknown APP_ENV", err)
+		}
+	})
+
+	t.Run("APP_ENV from .env", func(t *testing.T) {
+		cfg, err := loadWith(t, map[string]string{".env": "APP_ENV=test\n"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Env != Test {
+			t.Errorf("Env = %q, want test", cfg.Env)
+		}
+	})
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		env       map[string]string
+		wantPort  string
+		wantCSRF  Secret
+		wantError string
+	}{
+		{
+			name:     "profile defaults",
+			wantPort: "3000",
+		},
+		{
+			name:     "config.yaml over defaults",
+			files:    map[string]string{"config.yaml": "port: \"4000\"\n"},
+			wantPort: "4000",
+		},
+		{
+			name: "profile file over config.yaml",
+			files: map[string]string{
+				"config.yaml":             "port: \"4000\"\n",
+				"config.development.yaml": "port: \"4001\"\n",
+				"config.production.yaml":  "port: \"4009\"\n",
+			},
+			wantPort: "4001",
+		},
+		{
+			name: ".env over files",
+			files: map[string]string{
+				"config.yaml": "port: \"4000\"\n",
+				".env":        "PORT=4002\n",
+			},
+			wantPort: "4002",
+		},
+		{
+			name:     "environment over .env",
+			files:    map[string]string{".env": "PORT=4002\n"},
+			env:      map[string]string{"PORT": "4003"},
+			wantPort: "4003",
+		},
+		{
+			name:     "empty values are unset",
+			files:    map[string]string{".env": "PORT=\n"},
+			env:      map[string]string{"PORT": ""},
+			wantPort: "3000",
+		},
+		{
+			name: "secrets.yaml over config.yaml",
+			files: map[string]string{
+				"config.yaml":  "secrets:\n  csrf_key: " + string(testKey(1)) + "\n",
+				"secrets.yaml": "csrf_key: " + string(testKey(2)) + "\n",
+			},
+			wantPort: "3000",
+			wantCSRF: testKey(2),
+		},
+		{
+			name: ".env over secrets.yaml",
+			files: map[string]string{
+				"secrets.yaml": "csrf_key: " + string(testKey(2)) + "\n",
+				".env":         "CSRF_KEY=" + string(testKey(3)) + "\n",
+			},
+			wantPort: "3000",
+			wantCSRF: testKey(3),
+		},
+		{
+			name:      "unknown keys are errors",
+			files:     map[string]string{"config.yaml": "prot: \"4000\"\n"},
+			wantError: "failed to parse",
+		},
+		{
+			name:      "invalid values are errors",
+			env:       map[string]string{"PORT": "http", "BASE_URL": "http://localhost"},
+			wantError: `PORT "http" must be a number`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadWith(t, tt.files, tt.env)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Port != tt.wantPort {
+				t.Errorf("Port = %q, want %q", cfg.Port, tt.wantPort)
+			}
+			if tt.wantCSRF != "" && cfg.Secrets.CSRFKey != tt.wantCSRF {
+				t.Errorf("CSRFKey = %q, want %q", cfg.Secrets.CSRFKey.Value(), tt.wantCSRF.Value())
+			}
+		})
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	keyFile := map[string]string{"csrf_key": string(testKey(4)) + "\n"}
+
+	tests := []struct {
+		name      string
+		files     map[string]string
+		env       map[string]string
+		wantCSRF  Secret
+		wantPort  string
+		wantError string
+	}{
+		{
+			name:     "read when the variable is unset",
+			files:    keyFile,
+			env:      map[string]string{"CSRF_KEY_FILE": "$DIR/csrf_key"},
+			wantCSRF: testKey(4),
+		},
+		{
+			name:     "named in .env",
+			files:    map[string]string{"csrf_key": keyFile["csrf_key"], ".env": "CSRF_KEY_FILE=$DIR/csrf_key\n"},
+			wantCSRF: testKey(4),
+		},
+		{
+			name:     "the variable wins",
+			files:    keyFile,
+			env:      map[string]string{"CSRF_KEY": string(testKey(5)), "CSRF_KEY_FILE": "$DIR/csrf_key"},
+			wantCSRF: testKey(5),
+		},
+		{
+			name:     "the file wins over secrets.yaml",
+			files:    map[string]string{"csrf_key": keyFile["csrf_key"], "secrets.yaml": "csrf_key: " + string(testKey(2)) + "\n"},
+			env:      map[string]string{"CSRF_KEY_FILE": "$DIR/csrf_key"},
+			wantCSRF: testKey(4),
+		},
+		{
+			name:     "only for secrets",
+			files:    map[string]string{"port": "4000\n"},
+			env:      map[string]string{"PORT_FILE": "$DIR/port"},
+			wantPort: "3000",
+		},
+		{
+			name:      "missing file",
+			env:       map[string]string{"CSRF_KEY_FILE": "$DIR/missing"},
+			wantError: "failed to read CSRF_KEY_FILE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadWith(t, tt.files, tt.env)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantCSRF != "" && cfg.Secrets.CSRFKey != tt.wantCSRF {
+				t.Errorf("CSRFKey = %q, want %q", cfg.Secrets.CSRFKey.Value(), tt.wantCSRF.Value())
+			}
+			if tt.wantPort != "" && cfg.Port != tt.wantPort {
+				t.Errorf("Port = %q, want %q", cfg.Port, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestLoadRequiredKeys(t *testing.T) {
+	required := map[string]string{
+		"APP_ENV":        Production,
+		"BASE_URL":       "https://example.com",
+		"MAIL_FROM":      "noreply@example.com",
+		"CSRF_KEY":       string(testKey(1)),
+		"SESSION_SECRET": string(testKey(2)),
+	}
+	without := func(keys ...string) map[string]string {
+		env := map[string]string{}
+		for key, value := range required {
+			if !slices.Contains(keys, key) {
+				env[key] = value
+			}
+		}
+		return env
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{"all set", required, nil},
+		{"nothing set", map[string]string{"APP_ENV": Production}, []string{"BASE_URL", "CSRF_KEY", "MAIL_FROM", "SESSION_SECRET"}},
+		{"keys aren't generated in production", without("CSRF_KEY", "SESSION_SECRET"), []string{"CSRF_KEY", "SESSION_SECRET"}},
+		{"base URL doesn't follow the port in production", without("BASE_URL"), []string{"BASE_URL"}},
+		{"development has defaults", without("APP_ENV", "MAIL_FROM"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadWith(t, nil, tt.env)
+			var missing *MissingKeysError
+			if !errors.As(err, &missing) {
+				if tt.want != nil || err != nil {
+					t.Fatalf("err = %v, want missing %v", err, tt.want)
+				}
+				return
+			}
+			if !slices.Equal(missing.Keys, tt.want) {
+				t.Errorf("missing %v, want %v", missing.Keys, tt.want)
+			}
+		})
+	}
+}
+
+type testSettings struct {
+	Name     string        `env:"NAME" required:"true"`
+	On       bool          `env:"ON"`
+	Count    int           `env:"COUNT"`
+	Timeout  time.Duration `env:"TIMEOUT"`
+	Tags     []string      `env:"TAGS"`
+	Keys     []Secret      `env:"KEYS"`
+	Key      Secret        `env:"KEY" required:"true"`
+	Untagged string
+	Nested   struct {
+		Inner string `env:"INNER" required:"true"`
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		vars      map[string]string
+		want      func(s testSettings) bool
+		wantError string
+	}{
+		{
+			name: "strings and nested structs",
+			vars: map[string]string{"NAME": "bee", "INNER": "hive", "Untagged": "x"},
+			want: func(s testSettings) bool { return s.Name == "bee" && s.Nested.Inner == "hive" && s.Untagged == "" },
+		},
+		{
+			name: "bools, ints and durations",
+			vars: map[string]string{"ON": "true", "COUNT": "3", "TIMEOUT": "90m"},
+			want: func(s testSettings) bool { return s.On && s.Count == 3 && s.Timeout == 90*time.Minute },
+		},
+		{
+			name: "lists split on commas and newlines",
+			vars: map[string]string{"TAGS": "a, b,,\nc\n", "KEYS": "k1,k2"},
+			want: func(s testSettings) bool {
+				return slices.Equal(s.Tags, []string{"a", "b", "c"}) && slices.Equal(s.Keys, []Secret{"k1", "k2"})
+			},
+		},
+		{
+			name:      "invalid bool",
+			vars:      map[string]string{"ON": "yes please"},
+			wantError: `ON "yes please" must be true or false`,
+		},
+		{
+			name:      "invalid int",
+			vars:      map[string]string{"COUNT": "three"},
+			wantError: `COUNT "three" must be a number`,
+		},
+		{
+			name:      "invalid duration",
+			vars:      map[string]string{"TIMEOUT": "7"},
+			wantError: `TIMEOUT "7" must be a duration`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := func(key string) (string, bool) {
+				value, ok := tt.vars[key]
+				return value, ok
+			}
+
+			var s testSettings
+			err := applyEnv(reflect.ValueOf(&s).Elem(), lookup)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.want(s) {
+				t.Errorf("applyEnv() set %+v", s)
+			}
+		})
+	}
+}
+
+func TestMissingKeys(t *testing.T) {
+	var s testSettings
+	if got, want := missingKeys(reflect.ValueOf(s)), []string{"INNER", "KEY", "NAME"}; !slices.Equal(got, want) {
+		t.Errorf("missingKeys() = %v, want %v", got, want)
+	}
+
+	s.Name, s.Key, s.Nested.Inner = "bee", "key", "hive"
+	if got := missingKeys(reflect.ValueOf(s)); len(got) != 0 {
+		t.Errorf("missingKeys() = %v, want none", got)
+	}
+}
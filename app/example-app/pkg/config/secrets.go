@@ -0,0 +1,114 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// keyLength is the length of the keys in Secrets, in bytes
+const keyLength = 32
+
+// Secrets holds the app's keys. Keys are hex encoded 32-byte values,
+// e.g. from `openssl rand -hex 32`.
+type Secrets struct {
+	// CSRFKey signs CSRF cookies. Sharing it lets forms survive restarts
+	// and work across instances.
+	CSRFKey Secret `yaml:"csrf_key" env:"CSRF_KEY" required:"true"`
+	// CSRFPreviousKeys are still accepted after rotating CSRFKey
+	CSRFPreviousKeys []Secret `yaml:"csrf_previous_keys" env:"CSRF_PREVIOUS_KEYS"`
+	// SessionSecret signs and encrypts session data
+	SessionSecret Secret `yaml:"session_secret" env:"SESSION_SECRET" required:"true"`
+}
+
+// Secret is a config value that is redacted when printed or logged
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "[redacted]"
+}
+
+func (s Secret) GoString() string {
+	return `"` + s.String() + `"`
+}
+
+// Value returns the secret itself
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// Key decodes a hex encoded key
+func (s Secret) Key() ([]byte, error) {
+	key, err := hex.DecodeString(string(s))
+	if err != nil {
+		return nil, fmt.Errorf("not hex encoded")
+	}
+	if len(key) != keyLength {
+		return nil, fmt.Errorf("must be %d bytes (%d hex characters), got %d bytes", keyLength, keyLength*2, len(key))
+	}
+	return key, nil
+}
+
+// CSRFKeys returns the current CSRF key followed by the previous ones
+func (s Secrets) CSRFKeys() ([][]byte, error) {
+	keys := make([][]byte, 0, 1+len(s.CSRFPreviousKeys))
+	for i, secret := range append([]Secret{s.CSRFKey}, s.CSRFPreviousKeys...) {
+		key, err := secret.Key()
+		if err != nil {
+			if i == 0 {
+				return nil, fmt.Errorf("config: CSRF_KEY %w", err)
+			}
+			return nil, fmt.Errorf("config: CSRF_PREVIOUS_KEYS entry %d %w", i, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// validate checks that the keys decode
+func (s Secrets) validate() error {
+	if _, err := s.CSRFKeys(); err != nil {
+		return err
+	}
+	if _, err := s.SessionSecret.Key(); err != nil {
+		return fmt.Errorf("config: SESSION_SECRET %w", err)
+	}
+	return nil
+}
+
+// inherit sets missing keys to those of prev
+func (s *Secrets) inherit(prev *Secrets) {
+	if s.CSRFKey == "" {
+		s.CSRFKey = prev.CSRFKey
+	}
+	if s.SessionSecret == "" {
+		s.SessionSecret = prev.SessionSecret
+	}
+}
+
+// fillEphemeral sets missing keys to random values and returns the names
+// of the keys it generated
+func (s *Secrets) fillEphemeral() ([]string, error) {
+	var generated []string
+	for _, secret := range []struct {
+		name  string
+		value *Secret
+	}{
+		{"CSRF_KEY", &s.CSRFKey},
+		{"SESSION_SECRET", &s.SessionSecret},
+	} {
+		if *secret.value != "" {
+			continue
+		}
+		key := make([]byte, keyLength)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate %s: %w", secret.name, err)
+		}
+		*secret.value = Secret(hex.EncodeToString(key))
+		generated = append(generated, secret.name)
+	}
+	return generated, nil
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCSRFKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		secrets   Secrets
+		want      []byte
+		wantError string
+	}{
+		{
+			name:    "current key only",
+			secrets: Secrets{CSRFKey: testKey(1)},
+			want:    []byte{0x11},
+		},
+		{
+			name:    "current key first",
+			secrets: Secrets{CSRFKey: testKey(1), CSRFPreviousKeys: []Secret{testKey(2), testKey(3)}},
+			want:    []byte{0x11, 0x22, 0x33},
+		},
+		{
+			name:      "missing current key",
+			wantError: "CSRF_KEY must be 32 bytes",
+		},
+		{
+			name:      "short current key",
+			secrets:   Secrets{CSRFKey: "abcd"},
+			wantError: "CSRF_KEY must be 32 bytes",
+		},
+		{
+			name:      "invalid previous key",
+			secrets:   Secrets{CSRFKey: testKey(1), CSRFPreviousKeys: []Secret{testKey(2), "not hex"}},
+			wantError: "CSRF_PREVIOUS_KEYS entry 2 not hex encoded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := tt.secrets.CSRFKeys()
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(keys) != len(tt.want) {
+				t.Fatalf("got %d keys, want %d", len(keys), len(tt.want))
+			}
+			for i, b := range tt.want {
+				if !bytes.Equal(keys[i], bytes.Repeat([]byte{b}, keyLength)) {
+					t.Errorf("key %d = %x, want %x repeated", i, keys[i], b)
+				}
+			}
+		})
+	}
+}
+
+func TestFillEphemeral(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets Secrets
+		want    []string
+	}{
+		{"both missing", Secrets{}, []string{"CSRF_KEY", "SESSION_SECRET"}},
+		{"session secret missing", Secrets{CSRFKey: testKey(1)}, []string{"SESSION_SECRET"}},
+		{"none missing", Secrets{CSRFKey: testKey(1), SessionSecret: testKey(2)}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.secrets
+			generated, err := s.fillEphemeral()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(generated, tt.want) {
+				t.Errorf("generated %v, want %v", generated, tt.want)
+			}
+			if tt.secrets.CSRFKey != "" && s.CSRFKey != tt.secrets.CSRFKey {
+				t.Error("CSRF_KEY was replaced")
+			}
+			if tt.secrets.SessionSecret != "" && s.SessionSecret != tt.secrets.SessionSecret {
+				t.Error("SESSION_SECRET was replaced")
+			}
+			if err := s.validate(); err != nil {
+				t.Errorf("generated keys are invalid: %v", err)
+			}
+		})
+	}
+
+	t.Run("random", func(t *testing.T) {
+		var a, b Secrets
+		a.fillEphemeral()
+		b.fillEphemeral()
+		if a.CSRFKey == b.CSRFKey || a.CSRFKey == a.SessionSecret {
+			t.Error("generated keys repeat")
+		}
+	})
+}
+
+func TestInherit(t *testing.T) {
+	prev := &Secrets{CSRFKey: testKey(1), SessionSecret: testKey(2), CSRFPreviousKeys: []Secret{testKey(3)}}
+
+	tests := []struct {
+		name    string
+		secrets Secrets
+		want    Secrets
+	}{
+		{
+			name:    "missing keys are kept",
+			secrets: Secrets{},
+			want:    Secrets{CSRFKey: testKey(1), SessionSecret: testKey(2)},
+		},
+		{
+			name:    "set keys win",
+			secrets: Secrets{CSRFKey: testKey(4)},
+			want:    Secrets{CSRFKey: testKey(4), SessionSecret: testKey(2)},
+		},
+		{
+			name:    "previous keys aren't inherited",
+			secrets: Secrets{CSRFKey: testKey(4), SessionSecret: testKey(5)},
+			want:    Secrets{CSRFKey: testKey(4), SessionSecret: testKey(5)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.secrets
+			s.inherit(prev)
+			if s.CSRFKey != tt.want.CSRFKey || s.SessionSecret != tt.want.SessionSecret || len(s.CSRFPreviousKeys) != 0 {
+				t.Errorf("inherit() = %#v, want %#v", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretRedacted(t *testing.T) {
+	s := Secrets{CSRFKey: testKey(1)}
+	for _, out := range []string{fmt.Sprint(s), fmt.Sprintf("%v %+v %#v %s", s, s, s, s.CSRFKey)} {
+		if strings.Contains(out, string(testKey(1))) {
+			t.Errorf("printed the key: %s", out)
+		}
+	}
+}
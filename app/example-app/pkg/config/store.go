@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce collapses the burst of events editors produce when saving
+const reloadDebounce = 200 * time.Millisecond
+
+// Store holds the current config and swaps it atomically on reload.
+// Settings tagged reload:"true" take effect on reload; changes to the
+// others are logged and need a restart.
+type Store struct {
+	dir     string
+	current atomic.Pointer[Config]
+}
+
+// NewStore creates a store holding cfg, which was loaded from dir
+func NewStore(cfg *Config, dir string) *Store {
+	s := &Store{dir: dir}
+	s.current.Store(cfg)
+	return s
+}
+
+// Current returns the current config. The returned config is never
+// modified, so read it once per request for consistent values.
+func (s *Store) Current() *Config {
+	return s.current.Load()
+}
+
+// Reload loads the config again and applies the reloadable settings. An
+// invalid config is reported and the current one kept.
+func (s *Store) Reload() error {
+	old := s.Current()
+
+	loaded, err := load(s.dir, &old.Secrets)
+	if err != nil {
+		return err
+	}
+
+	next, changed, needRestart := mergeReloadable(old, loaded)
+	if len(needRestart) > 0 {
+		log.Printf("⚠️  Config: %s changed, restart to apply", strings.Join(needRestart, ", "))
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	s.current.Store(next)
+	log.Printf("🔧 Config reloaded: %s changed", strings.Join(changed, ", "))
+	return nil
+}
+
+// Watch reloads the config on SIGHUP and whenever one of the files Load
+// reads changes. It returns a function that stops watching.
+func (s *Store) Watch() (func(), error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch config: %w", err)
+	}
+	// Editors often replace files instead of writing them, so watch the
+	// directory rather than the files
+	if err := watcher.Add(s.dir); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", s.dir, err)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	done := make(chan struct{})
+	go func() {
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-done:
+				return
+			case <-hup:
+				s.reloadAndLog()
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if s.isConfigFile(event.Name) && !event.Has(fsnotify.Chmod) {
+					debounce = time.After(reloadDebounce)
+				}
+			case <-debounce:
+				debounce = nil
+				s.reloadAndLog()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("⚠️  Config watcher: %v", err)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(hup)
+			close(done)
+			watcher.Close()
+		})
+	}, nil
+}
+
+func (s *Store) reloadAndLog() {
+	if err := s.Reload(); err != nil {
+		log.Printf("⚠️  Config not reloaded, keeping the current one: %v", err)
+	}
+}
+
+// isConfigFile reports whether path is one of the files Load reads
+func (s *Store) isConfigFile(path string) bool {
+	switch filepath.Base(path) {
+	case "config.yaml", "config." + s.Current().Env + ".yaml", "secrets.yaml", ".env":
+		return true
+	}
+	return false
+}
+
+// mergeReloadable returns old with the reloadable settings taken from
+// loaded, the env names of the settings that changed, and those that
+// changed but can't be reloaded
+func mergeReloadable(old, loaded *Config) (*Config, []string, []string) {
+	next := *old
+	var changed, needRestart []string
+	mergeFields(reflect.ValueOf(&next).Elem(), reflect.ValueOf(loaded).Elem(), &changed, &needRestart)
+	return &next, changed, needRestart
+}
+
+func mergeFields(next, loaded reflect.Value, changed, needRestart *[]string) {
+	t := next.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			mergeFields(next.Field(i), loaded.Field(i), changed, needRestart)
+			continue
+		}
+
+		if reflect.DeepEqual(next.Field(i).Interface(), loaded.Field(i).Interface()) {
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		if name == "" {
+			name = field.Name
+		}
+		if field.Tag.Get("reload") != "true" {
+			*needRestart = append(*needRestart, name)
+			continue
+		}
+		next.Field(i).Set(loaded.Field(i))
+		*changed = append(*changed, name)
+	}
+}
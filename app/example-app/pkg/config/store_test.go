@@ -0,0 +1,158 @@
+package config
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMergeReloadable(t *testing.T) {
+	old := &Config{
+		Port:          "3000",
+		DBFile:        "./app.db",
+		EmailConfig:   EmailConfig{From: "old@example.com", Name: "Old"},
+		AuthConfig:    AuthConfig{ConfirmEmail: true},
+		SessionConfig: SessionConfig{Store: "sqlite", IdleTimeout: time.Hour},
+		Secrets:       Secrets{CSRFKey: testKey(1), CSRFPreviousKeys: []Secret{testKey(2)}},
+	}
+
+	tests := []struct {
+		name            string
+		change          func(c *Config)
+		want            func(c *Config) bool
+		wantChanged     []string
+		wantNeedRestart []string
+	}{
+		{
+			name:   "nothing changed",
+			change: func(c *Config) {},
+			want:   func(c *Config) bool { return c.EmailConfig.From == "old@example.com" },
+		},
+		{
+			name: "reloadable settings are taken",
+			change: func(c *Config) {
+				c.EmailConfig.From = "new@example.com"
+				c.AuthConfig.ConfirmEmail = false
+			},
+			want: func(c *Config) bool {
+				return c.EmailConfig.From == "new@example.com" && !c.AuthConfig.ConfirmEmail
+			},
+			wantChanged: []string{"MAIL_FROM", "AUTH_CONFIRM_EMAIL"},
+		},
+		{
+			name: "other settings are kept",
+			change: func(c *Config) {
+				c.Port = "4000"
+				c.DBFile = "./other.db"
+				c.SessionConfig.IdleTimeout = 2 * time.Hour
+				c.Secrets.CSRFKey = testKey(3)
+				c.Secrets.CSRFPreviousKeys = []Secret{testKey(1), testKey(2)}
+			},
+			want: func(c *Config) bool {
+				return c.Port == "3000" && c.DBFile == "./app.db" && c.SessionConfig.IdleTimeout == time.Hour &&
+					c.Secrets.CSRFKey == testKey(1) && slices.Equal(c.Secrets.CSRFPreviousKeys, []Secret{testKey(2)})
+			},
+			wantNeedRestart: []string{"PORT", "DB_FILE", "SESSION_IDLE_TIMEOUT", "CSRF_KEY", "CSRF_PREVIOUS_KEYS"},
+		},
+		{
+			name: "both",
+			change: func(c *Config) {
+				c.Port = "4000"
+				c.EmailConfig.Name = "New"
+			},
+			want: func(c *Config) bool {
+				return c.Port == "3000" && c.EmailConfig.Name == "New"
+			},
+			wantChanged:     []string{"MAIL_NAME"},
+			wantNeedRestart: []string{"PORT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded := *old
+			loaded.Secrets.CSRFPreviousKeys = slices.Clone(old.Secrets.CSRFPreviousKeys)
+			tt.change(&loaded)
+
+			next, changed, needRestart := mergeReloadable(old, &loaded)
+			if !tt.want(next) {
+				t.Errorf("merged %+v", next)
+			}
+			if !slices.Equal(changed, tt.wantChanged) {
+				t.Errorf("changed %v, want %v", changed, tt.wantChanged)
+			}
+			if !slices.Equal(needRestart, tt.wantNeedRestart) {
+				t.Errorf("need restart %v, want %v", needRestart, tt.wantNeedRestart)
+			}
+			if old.EmailConfig.From != "old@example.com" || old.EmailConfig.Name != "Old" || !old.AuthConfig.ConfirmEmail {
+				t.Error("the old config was modified")
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	isolate(t)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	dir := t.TempDir()
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("email:\n  from: old@example.com\n")
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(cfg, dir)
+
+	t.Run("unchanged", func(t *testing.T) {
+		if err := store.Reload(); err != nil {
+			t.Fatal(err)
+		}
+		// Keys generated for the run are kept rather than generated again
+		if store.Current() != cfg {
+			t.Error("reloading an unchanged config replaced it")
+		}
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		write("port: \"4000\"\nemail:\n  from: new@example.com\n")
+		if err := store.Reload(); err != nil {
+			t.Fatal(err)
+		}
+		current := store.Current()
+		if current.EmailConfig.From != "new@example.com" {
+			t.Errorf("MAIL_FROM = %q, want the reloaded value", current.EmailConfig.From)
+		}
+		if current.Port != "3000" {
+			t.Errorf("PORT = %q, want it kept until a restart", current.Port)
+		}
+		if current.Secrets.CSRFKey != cfg.Secrets.CSRFKey || current.Secrets.SessionSecret != cfg.Secrets.SessionSecret {
+			t.Error("the keys changed on reload")
+		}
+		if cfg.EmailConfig.From != "old@example.com" {
+			t.Error("the previous snapshot was modified")
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		before := store.Current()
+		write("email:\n  from: [\n")
+		if err := store.Reload(); err == nil {
+			t.Fatal("Reload() = nil, want an error")
+		}
+		if store.Current() != before {
+			t.Error("an invalid config replaced the current one")
+		}
+	})
+}
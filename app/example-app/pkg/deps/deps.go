@@ -0,0 +1,66 @@
+package deps
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/nick-friedrich/beesting/app/example-app/db"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/config"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/flags"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/mail"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/session"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/validation"
+)
+
+// Deps holds the services handlers and tasks use. Each app instance has
+// its own, so tests can build one with fakes and run in parallel.
+type Deps struct {
+	Config    *config.Store
+	Queries   *db.Queries
+	Mailer    *mail.Mailer
+	Sessions  *session.SessionManager
+	Flags     *flags.Flags
+	Validator *validation.Validator
+	Logger    *slog.Logger
+}
+
+// New creates the dependencies for the config in store, backed by queries
+// and sending mail through adapter
+func New(store *config.Store, queries *db.Queries, adapter mail.MailerAdapter, logger *slog.Logger) (*Deps, error) {
+	cfg := store.Current()
+	sessionStore, err := newSessionStore(cfg, queries)
+	if err != nil {
+		return nil, err
+	}
+	sessions := session.NewSessionManager(sessionStore, session.Options{
+		IdleTimeout:   cfg.SessionConfig.IdleTimeout,
+		MaxLifetime:   cfg.SessionConfig.MaxLifetime,
+		TouchInterval: cfg.SessionConfig.TouchInterval,
+	})
+
+	return &Deps{
+		Config:    store,
+		Queries:   queries,
+		Mailer:    mail.NewMailer(adapter),
+		Sessions:  sessions,
+		Flags:     flags.New(queries),
+		Validator: validation.NewValidator(),
+		Logger:    logger,
+	}, nil
+}
+
+// newSessionStore creates the session store selected by SESSION_STORE
+func newSessionStore(cfg *config.Config, queries *db.Queries) (session.Store, error) {
+	switch cfg.SessionConfig.Store {
+	case session.StoreMemory:
+		return session.NewMemoryStore(), nil
+	case session.StoreCookie:
+		secret, err := cfg.Secrets.SessionSecret.Key()
+		if err != nil {
+			return nil, fmt.Errorf("config: SESSION_SECRET %w", err)
+		}
+		return session.NewCookieStore(secret, cfg.SessionConfig.MaxLifetime), nil
+	default:
+		return session.NewSQLiteStore(queries), nil
+	}
+}
@@ -0,0 +1,139 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/nick-friedrich/beesting/app/example-app/db"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/session"
+)
+
+// keyPattern restricts flag keys to names that are safe in URLs
+var keyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// Flags evaluates the feature flags stored in the app database
+type Flags struct {
+	queries *db.Queries
+}
+
+// New creates flags backed by queries
+func New(queries *db.Queries) *Flags {
+	return &Flags{queries: queries}
+}
+
+// ValidateKey checks that key can be used as a flag key
+func ValidateKey(key string) error {
+	if len(key) > 100 {
+		return errors.New("key cannot be longer than 100 characters")
+	}
+	if !keyPattern.MatchString(key) {
+		return errors.New("key can only contain lowercase letters, numbers, hyphens, underscores and dots")
+	}
+	return nil
+}
+
+// Evaluate reports whether flag is on for user. A disabled flag is off for
+// everyone. An enabled flag is on for the users and roles it lists, and for
+// RolloutPercent percent of the other logged in users, picked by a stable
+// hash so each user keeps the same answer. At 100 percent it is on for
+// everyone, including visitors who aren't logged in.
+func Evaluate(flag db.FeatureFlag, user *session.SessionData) bool {
+	if !flag.Enabled {
+		return false
+	}
+	if flag.RolloutPercent >= 100 {
+		return true
+	}
+	if user == nil || !user.LoggedIn {
+		return false
+	}
+	if slices.Contains(SplitList(flag.UserIds), user.UserID) {
+		return true
+	}
+	if user.UserRole != "" && slices.Contains(SplitList(flag.Roles), user.UserRole) {
+		return true
+	}
+	return bucket(flag.Key, user.UserID) < flag.RolloutPercent
+}
+
+// bucket maps a user to 0-99 per flag, so rollouts of different flags
+// don't all pick the same users
+func bucket(key, userID string) int64 {
+	h := fnv.New32a()
+	h.Write([]byte(key + ":" + userID))
+	return int64(h.Sum32() % 100)
+}
+
+// SplitList splits a comma separated list of roles or user IDs
+func SplitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// NormalizeList trims and dedupes a comma separated list for storage
+func NormalizeList(list string) string {
+	items := SplitList(list)
+	slices.Sort(items)
+	return strings.Join(slices.Compact(items), ",")
+}
+
+// evaluator answers flag lookups for one request. Flags are loaded on the
+// first lookup, so requests that check no flags cost nothing.
+type evaluator struct {
+	flags *Flags
+	r     *http.Request
+
+	once  sync.Once
+	byKey map[string]db.FeatureFlag
+}
+
+func (e *evaluator) on(key string) bool {
+	e.once.Do(func() {
+		e.byKey = map[string]db.FeatureFlag{}
+		// Flags that can't be loaded are off rather than failing the page
+		list, err := e.flags.queries.ListFeatureFlags(e.r.Context())
+		if err != nil {
+			return
+		}
+		for _, flag := range list {
+			e.byKey[flag.Key] = flag
+		}
+	})
+
+	flag, ok := e.byKey[key]
+	return ok && Evaluate(flag, session.FromContext(e.r.Context()))
+}
+
+type contextKey struct{}
+
+// Middleware makes the flags available to On for the rest of the request.
+// It targets the user stored by the session middleware, so it must run
+// after it.
+func (f *Flags) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			e := &evaluator{flags: f}
+			r = r.WithContext(context.WithValue(r.Context(), contextKey{}, e))
+			e.r = r
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// On reports whether the flag key is on for the user of the request in ctx.
+// Unknown flags, and requests that didn't pass through Middleware, are off.
+func On(ctx context.Context, key string) bool {
+	e, ok := ctx.Value(contextKey{}).(*evaluator)
+	return ok && e.on(key)
+}
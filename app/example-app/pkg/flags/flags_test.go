@@ -0,0 +1,135 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nick-friedrich/beesting/app/example-app/db"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/session"
+)
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key string
+		ok  bool
+	}{
+		{"new-editor", true},
+		{"beta_2.checkout", true},
+		{"0day", true},
+		{strings.Repeat("a", 100), true},
+		{strings.Repeat("a", 101), false},
+		{"", false},
+		{"New-Editor", false},
+		{"-leading", false},
+		{".hidden", false},
+		{"with space", false},
+		{"a/b", false},
+		{"a?b", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateKey(tt.key)
+		if tt.ok && err != nil {
+			t.Errorf("ValidateKey(%q) = %v, want nil", tt.key, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("ValidateKey(%q) = nil, want an error", tt.key)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	visitor := &session.SessionData{LoggedIn: false}
+	user := &session.SessionData{LoggedIn: true, UserID: "u1", UserRole: "user"}
+	admin := &session.SessionData{LoggedIn: true, UserID: "u2", UserRole: "admin"}
+
+	tests := []struct {
+		name string
+		flag db.FeatureFlag
+		user *session.SessionData
+		want bool
+	}{
+		{"disabled", db.FeatureFlag{Key: "f", RolloutPercent: 100, UserIds: "u1"}, user, false},
+		{"everyone", db.FeatureFlag{Key: "f", Enabled: true, RolloutPercent: 100}, user, true},
+		{"everyone includes visitors", db.FeatureFlag{Key: "f", Enabled: true, RolloutPercent: 100}, visitor, true},
+		{"everyone includes no session", db.FeatureFlag{Key: "f", Enabled: true, RolloutPercent: 100}, nil, true},
+		{"nobody", db.FeatureFlag{Key: "f", Enabled: true}, user, false},
+		{"listed user", db.FeatureFlag{Key: "f", Enabled: true, UserIds: "u0, u1"}, user, true},
+		{"unlisted user", db.FeatureFlag{Key: "f", Enabled: true, UserIds: "u0,u10"}, user, false},
+		{"listed role", db.FeatureFlag{Key: "f", Enabled: true, Roles: "admin,beta"}, admin, true},
+		{"unlisted role", db.FeatureFlag{Key: "f", Enabled: true, Roles: "admin,beta"}, user, false},
+		{"partial rollout skips visitors", db.FeatureFlag{Key: "f", Enabled: true, RolloutPercent: 99}, visitor, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Evaluate(tt.flag, tt.user); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateRollout(t *testing.T) {
+	flag := db.FeatureFlag{Key: "new-editor", Enabled: true, RolloutPercent: 30}
+
+	on := map[string]bool{}
+	for i := range 10000 {
+		user := &session.SessionData{LoggedIn: true, UserID: fmt.Sprint(i)}
+		on[user.UserID] = Evaluate(flag, user)
+	}
+
+	count := 0
+	for _, v := range on {
+		if v {
+			count++
+		}
+	}
+	if count < 2700 || count > 3300 {
+		t.Errorf("%d of 10000 users got a 30%% rollout", count)
+	}
+
+	// Users keep the flag as the rollout grows
+	flag.RolloutPercent = 60
+	for id, was := range on {
+		if was && !Evaluate(flag, &session.SessionData{LoggedIn: true, UserID: id}) {
+			t.Fatalf("user %s lost the flag when the rollout grew", id)
+		}
+	}
+}
+
+func TestBucketDiffersPerFlag(t *testing.T) {
+	same := 0
+	for i := range 1000 {
+		id := fmt.Sprint(i)
+		if bucket("a", id) == bucket("b", id) {
+			same++
+		}
+	}
+	// Independent buckets agree about 1% of the time
+	if same > 50 {
+		t.Errorf("%d of 1000 users share their bucket across flags", same)
+	}
+}
+
+func TestNormalizeList(t *testing.T) {
+	tests := map[string]string{
+		"":                   "",
+		" , ,":               "",
+		"beta, admin,beta ,": "admin,beta",
+		"u2,u1":              "u1,u2",
+	}
+	for in, want := range tests {
+		if got := NormalizeList(in); got != want {
+			t.Errorf("NormalizeList(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestOnWithoutMiddleware(t *testing.T) {
+	if On(context.Background(), "anything") {
+		t.Error("On() = true without the middleware, want false")
+	}
+}
@@ -1,9 +1,6 @@
 package mail
 
-import (
-	"errors"
-	"sync"
-)
+import "errors"
 
 type Mailer struct {
 	Adapter MailerAdapter
@@ -20,24 +17,9 @@ type Email struct {
 	Body    string
 }
 
-var (
-	mailerInstance *Mailer
-	once           sync.Once
-)
-
-func GetMailer() *Mailer {
-	once.Do(func() {
-		mailerInstance = &Mailer{}
-	})
-	return mailerInstance
-}
-
-func InitMailer(adapter MailerAdapter) {
-	once.Do(func() {
-		mailerInstance = &Mailer{
-			Adapter: adapter,
-		}
-	})
+// NewMailer creates a mailer that sends through adapter
+func NewMailer(adapter MailerAdapter) *Mailer {
+	return &Mailer{Adapter: adapter}
 }
 
 func (m *Mailer) SendEmail(email *Email) error {
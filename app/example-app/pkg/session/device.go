@@ -0,0 +1,77 @@
+package session
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// maxUserAgentLength caps the stored user agent; real ones are far shorter
+const maxUserAgentLength = 512
+
+// browsers and platforms are matched against the user agent in order, so
+// more specific names come first (Edge and Chrome both claim to be Safari)
+var (
+	browsers = []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"CriOS/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	}
+	platforms = []struct{ token, name string }{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// DeviceLabel returns an approximate label like "Firefox on Windows" for a
+// user agent
+func DeviceLabel(userAgent string) string {
+	var browser, platform string
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, p := range platforms {
+		if strings.Contains(userAgent, p.token) {
+			platform = p.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return "Browser on " + platform
+	default:
+		return "Unknown device"
+	}
+}
+
+// deviceInfo returns the user agent, IP address and device label of r
+func deviceInfo(r *http.Request) (userAgent, ip, device string) {
+	userAgent = r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	ip = r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+
+	return userAgent, ip, DeviceLabel(userAgent)
+}
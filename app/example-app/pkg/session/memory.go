@@ -0,0 +1,119 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps sessions in memory under the hash of their token, for
+// tests and development. Sessions are lost on restart, and user details are
+// those at login.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+// NewMemoryStore creates an empty store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: map[string]Session{}}
+}
+
+func (st *MemoryStore) Save(ctx context.Context, s *Session) (string, error) {
+	token, err := generateSessionID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	s.ID = hashToken(token)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.sessions[s.ID] = *s
+	return token, nil
+}
+
+func (st *MemoryStore) Load(ctx context.Context, token string) (*Session, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	s, ok := st.sessions[hashToken(token)]
+	if !ok || !s.ExpiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return &s, nil
+}
+
+func (st *MemoryStore) Touch(ctx context.Context, token string, s *Session) (string, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	id := hashToken(token)
+	if stored, ok := st.sessions[id]; ok {
+		stored.LastAccessedAt = s.LastAccessedAt
+		stored.ExpiresAt = s.ExpiresAt
+		st.sessions[id] = stored
+	}
+	return token, nil
+}
+
+func (st *MemoryStore) Delete(ctx context.Context, token string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	delete(st.sessions, hashToken(token))
+	return nil
+}
+
+func (st *MemoryStore) DeleteByID(ctx context.Context, userID, id string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if s, ok := st.sessions[id]; ok && s.UserID == userID {
+		delete(st.sessions, id)
+	}
+	return nil
+}
+
+func (st *MemoryStore) DeleteUser(ctx context.Context, userID string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for id, s := range st.sessions {
+		if s.UserID == userID {
+			delete(st.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (st *MemoryStore) ListUser(ctx context.Context, userID string) ([]Session, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+	sessions := []Session{}
+	for _, s := range st.sessions {
+		if s.UserID == userID && s.ExpiresAt.After(now) {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastAccessedAt.After(sessions[j].LastAccessedAt)
+	})
+	return sessions, nil
+}
+
+func (st *MemoryStore) DeleteExpired(ctx context.Context) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+	for id, s := range st.sessions {
+		if !s.ExpiresAt.After(now) {
+			delete(st.sessions, id)
+		}
+	}
+	return nil
+}
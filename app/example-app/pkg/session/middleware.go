@@ -0,0 +1,80 @@
+package session
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"slices"
+)
+
+type contextKey struct{}
+
+// Middleware loads the session once per request and stores it in the
+// request context for FromContext. A store that fails is a server error
+// rather than a silent logout.
+func (sm *SessionManager) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, err := sm.GetSession(w, r)
+			if err != nil {
+				log.Printf("⚠️  Session: %v", err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), data)))
+		})
+	}
+}
+
+// NewContext returns a copy of ctx carrying data
+func NewContext(ctx context.Context, data *SessionData) context.Context {
+	return context.WithValue(ctx, contextKey{}, data)
+}
+
+// FromContext returns the session Middleware stored in ctx. Without one it
+// returns a logged out session, never nil.
+func FromContext(ctx context.Context) *SessionData {
+	if data, ok := ctx.Value(contextKey{}).(*SessionData); ok && data != nil {
+		return data
+	}
+	return &SessionData{LoggedIn: false}
+}
+
+// RequireLogin lets only logged in users through. Others are sent to the
+// login page, or get 401 Unauthorized for requests other than GET.
+func RequireLogin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !FromContext(r.Context()).LoggedIn {
+			denyLogin(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireRole lets only logged in users with one of roles through. Others
+// are handled like in RequireLogin, or get 403 Forbidden when logged in.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data := FromContext(r.Context())
+			if !data.LoggedIn {
+				denyLogin(w, r)
+				return
+			}
+			if !slices.Contains(roles, data.UserRole) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func denyLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
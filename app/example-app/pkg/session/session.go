@@ -3,9 +3,10 @@ package session
 import (
 	"context"
 	"crypto/rand"
-	"database/sql"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"time"
 
@@ -19,25 +20,48 @@ type SessionData struct {
 	Name     string
 	LoggedIn bool
 	UserRole string
+	// SessionID identifies the session among the user's sessions
+	SessionID string
+}
+
+// cookieName is the name of the session cookie
+const cookieName = "beesting_session"
+
+// Options sets how long sessions last. A session expires after
+// IdleTimeout without requests and at the latest MaxLifetime after login.
+// All options must be positive; the app's defaults live in its config.
+type Options struct {
+	IdleTimeout time.Duration
+	MaxLifetime time.Duration
+	// TouchInterval limits how often a request renews the session, so most
+	// requests only read from the store
+	TouchInterval time.Duration
 }
 
 // SessionManager handles session operations
 type SessionManager struct {
 	cookieName string
-	maxAge     int
-	queries    *db.Queries
+	opts       Options
+	store      Store
 }
 
-// Default is the global session manager instance
-var Default *SessionManager
-
-// NewSessionManager creates a new session manager
-func NewSessionManager(queries *db.Queries) *SessionManager {
+// NewSessionManager creates a new session manager keeping sessions in store
+func NewSessionManager(store Store, opts Options) *SessionManager {
 	return &SessionManager{
-		cookieName: "beesting_session",
-		maxAge:     7 * 24 * 60 * 60, // 7 days in seconds
-		queries:    queries,
+		cookieName: cookieName,
+		opts:       opts,
+		store:      store,
+	}
+}
+
+// expiresAt returns when a session created at createdAt and last used at
+// now expires: after the idle timeout, but never past the max lifetime
+func (sm *SessionManager) expiresAt(createdAt, now time.Time) time.Time {
+	idle := now.Add(sm.opts.IdleTimeout)
+	if absolute := createdAt.Add(sm.opts.MaxLifetime); absolute.Before(idle) {
+		return absolute
 	}
+	return idle
 }
 
 // generateSessionID generates a secure random session ID
@@ -49,85 +73,113 @@ func generateSessionID() (string, error) {
 	return base64.URLEncoding.EncodeToString(bytes), nil
 }
 
-// SetSession creates a new session and sets the cookie
-func (sm *SessionManager) SetSession(w http.ResponseWriter, userID, email, name string) error {
-	sessionID, err := generateSessionID()
-	if err != nil {
-		return fmt.Errorf("failed to generate session ID: %w", err)
+// Rotate ends the request's session, if any, and starts one under a new ID
+// for user, so a token planted or leaked before the call is worthless. Call
+// it on login and whenever the user's role or password changes. When the
+// request's session belongs to user, the new one keeps its login time, so
+// rotating doesn't extend MaxLifetime.
+func (sm *SessionManager) Rotate(w http.ResponseWriter, r *http.Request, user db.User) error {
+	now := time.Now()
+	createdAt := now
+
+	if cookie, err := r.Cookie(sm.cookieName); err == nil {
+		if old, err := sm.store.Load(r.Context(), cookie.Value); err == nil && old.UserID == user.ID {
+			createdAt = old.CreatedAt
+		}
+		if err := sm.store.Delete(r.Context(), cookie.Value); err != nil {
+			return fmt.Errorf("failed to delete previous session: %w", err)
+		}
 	}
 
-	// Calculate expiration time
-	expiresAt := time.Now().Add(time.Duration(sm.maxAge) * time.Second)
-
-	// Store session in database
-	_, err = sm.queries.CreateSession(context.Background(), db.CreateSessionParams{
-		ID:        sessionID,
-		UserID:    userID,
-		ExpiresAt: expiresAt,
-	})
+	userAgent, ip, device := deviceInfo(r)
+	session := &Session{
+		UserID:         user.ID,
+		Email:          user.Email,
+		Name:           user.Name,
+		Role:           user.Role,
+		CreatedAt:      createdAt,
+		ExpiresAt:      sm.expiresAt(createdAt, now),
+		LastAccessedAt: now,
+		UserAgent:      userAgent,
+		IPAddress:      ip,
+		Device:         device,
+	}
+	token, err := sm.store.Save(r.Context(), session)
 	if err != nil {
-		return fmt.Errorf("failed to create session in database: %w", err)
+		return err
 	}
 
-	// Set cookie with only the session token
-	cookie := &http.Cookie{
+	sm.setCookie(w, token, session.ExpiresAt)
+	return nil
+}
+
+// setCookie sets the session cookie to token, expiring with the session
+func (sm *SessionManager) setCookie(w http.ResponseWriter, token string, expiresAt time.Time) {
+	// Round up, as a MaxAge of 0 would make the cookie outlive the session
+	maxAge := max(int(math.Ceil(time.Until(expiresAt).Seconds())), 1)
+
+	http.SetCookie(w, &http.Cookie{
 		Name:     sm.cookieName,
-		Value:    sessionID,
+		Value:    token,
 		Path:     "/",
-		MaxAge:   sm.maxAge,
+		MaxAge:   maxAge,
 		HttpOnly: true,
 		Secure:   false, // Set to true in production with HTTPS
 		SameSite: http.SameSiteLaxMode,
-	}
-
-	http.SetCookie(w, cookie)
-	return nil
+	})
 }
 
-// GetSession retrieves session data from the cookie and database
-func (sm *SessionManager) GetSession(r *http.Request) (*SessionData, error) {
+// GetSession retrieves session data from the cookie and store. Sessions
+// last used more than TouchInterval ago are renewed, which extends their
+// expiry and refreshes the cookie.
+func (sm *SessionManager) GetSession(w http.ResponseWriter, r *http.Request) (*SessionData, error) {
 	cookie, err := r.Cookie(sm.cookieName)
 	if err != nil {
 		return &SessionData{LoggedIn: false}, nil
 	}
 
-	sessionToken := cookie.Value
-
-	// Get session from database
-	session, err := sm.queries.GetSession(context.Background(), sessionToken)
+	now := time.Now()
+	session, err := sm.store.Load(r.Context(), cookie.Value)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, ErrNotFound) {
 			// Session not found or expired
 			return &SessionData{LoggedIn: false}, nil
 		}
-		return &SessionData{LoggedIn: false}, fmt.Errorf("failed to get session: %w", err)
+		return &SessionData{LoggedIn: false}, err
+	}
+	// Stores check the stored ExpiresAt. Checking the configured limits too
+	// ends sessions right away when the limits are lowered.
+	if !now.Before(sm.expiresAt(session.CreatedAt, session.LastAccessedAt)) {
+		return &SessionData{LoggedIn: false}, nil
 	}
 
-	// Update last accessed time
-	_ = sm.queries.UpdateSessionAccess(context.Background(), sessionToken)
-
-	// Get user details
-	user, err := sm.queries.GetUser(context.Background(), session.UserID)
-	if err != nil {
-		return &SessionData{LoggedIn: false}, fmt.Errorf("failed to get user: %w", err)
+	if now.Sub(session.LastAccessedAt) > sm.opts.TouchInterval {
+		session.LastAccessedAt = now
+		session.ExpiresAt = sm.expiresAt(session.CreatedAt, now)
+		token, err := sm.store.Touch(r.Context(), cookie.Value, session)
+		if err != nil {
+			return &SessionData{LoggedIn: false}, err
+		}
+		sm.setCookie(w, token, session.ExpiresAt)
 	}
 
 	return &SessionData{
-		UserID:   user.ID,
-		UserRole: user.Role,
-		Email:    user.Email,
-		Name:     user.Name,
-		LoggedIn: true,
+		UserID:    session.UserID,
+		UserRole:  session.Role,
+		Email:     session.Email,
+		Name:      session.Name,
+		LoggedIn:  true,
+		SessionID: session.ID,
 	}, nil
 }
 
-// ClearSession removes the session from database and clears the cookie
+// ClearSession removes the session from the store and clears the cookie
 func (sm *SessionManager) ClearSession(w http.ResponseWriter, r *http.Request) error {
 	// Get session token from cookie
 	cookie, err := r.Cookie(sm.cookieName)
 	if err == nil {
-		// Delete session from database
-		_ = sm.queries.DeleteSession(context.Background(), cookie.Value)
+		// Delete session from the store
+		_ = sm.store.Delete(context.Background(), cookie.Value)
 	}
 
 	// Clear cookie
@@ -144,17 +196,40 @@ func (sm *SessionManager) ClearSession(w http.ResponseWriter, r *http.Request) e
 	return nil
 }
 
-// CleanupExpiredSessions removes all expired sessions from the database
+// CleanupExpiredSessions removes all expired sessions from the store
 func (sm *SessionManager) CleanupExpiredSessions() error {
-	return sm.queries.DeleteExpiredSessions(context.Background())
+	return sm.store.DeleteExpired(context.Background())
 }
 
 // DeleteUserSessions removes all sessions for a specific user
 func (sm *SessionManager) DeleteUserSessions(userID string) error {
-	return sm.queries.DeleteUserSessions(context.Background(), userID)
+	return sm.store.DeleteUser(context.Background(), userID)
+}
+
+// GetUserSessions retrieves all active sessions for a user. Stores that
+// can't list sessions return ErrNotSupported.
+func (sm *SessionManager) GetUserSessions(userID string) ([]Session, error) {
+	return sm.store.ListUser(context.Background(), userID)
+}
+
+// RevokeSession ends the session with id if it belongs to userID
+func (sm *SessionManager) RevokeSession(userID, id string) error {
+	return sm.store.DeleteByID(context.Background(), userID, id)
 }
 
-// GetUserSessions retrieves all active sessions for a user
-func (sm *SessionManager) GetUserSessions(userID string) ([]db.Session, error) {
-	return sm.queries.GetUserSessions(context.Background(), userID)
+// RevokeOtherSessions ends all sessions of userID except currentID
+func (sm *SessionManager) RevokeOtherSessions(userID, currentID string) error {
+	sessions, err := sm.GetUserSessions(userID)
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		if s.ID == currentID {
+			continue
+		}
+		if err := sm.RevokeSession(userID, s.ID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
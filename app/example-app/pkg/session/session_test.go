@@ -0,0 +1,206 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nick-friedrich/beesting/app/example-app/db"
+)
+
+func TestHashToken(t *testing.T) {
+	// SHA-256 of "abc"
+	const want = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := hashToken("abc"); got != want {
+		t.Errorf("hashToken(abc) = %s, want %s", got, want)
+	}
+	if hashToken("a") == hashToken("b") {
+		t.Error("different tokens hash alike")
+	}
+}
+
+func TestMemoryStoreKeepsOnlyHashes(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	s := &Session{UserID: "u1", ExpiresAt: time.Now().Add(time.Hour)}
+	token, err := store.Save(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.ID != hashToken(token) {
+		t.Errorf("ID = %s, want the hash of the token", s.ID)
+	}
+	if _, ok := store.sessions[token]; ok {
+		t.Error("the raw token is stored")
+	}
+	if _, err := store.Load(ctx, token); err != nil {
+		t.Errorf("Load(token) = %v", err)
+	}
+	// A leaked ID can't be used as a token
+	if _, err := store.Load(ctx, s.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load(ID) = %v, want ErrNotFound", err)
+	}
+}
+
+var testOptions = Options{
+	IdleTimeout:   time.Hour,
+	MaxLifetime:   24 * time.Hour,
+	TouchInterval: time.Minute,
+}
+
+// login rotates the session of a request carrying cookie, if set, and
+// returns the new cookie
+func login(t *testing.T, sm *SessionManager, user db.User, cookie *http.Cookie) *http.Cookie {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodPost, "/login", nil)
+	r.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:130.0) Gecko/20100101 Firefox/130.0")
+	r.RemoteAddr = "192.0.2.1:1234"
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+
+	w := httptest.NewRecorder()
+	if err := sm.Rotate(w, r, user); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range w.Result().Cookies() {
+		if c.Name == cookieName {
+			return c
+		}
+	}
+	t.Fatal("Rotate set no session cookie")
+	return nil
+}
+
+// load returns the session of cookie, or nil when there is none
+func load(t *testing.T, sm *SessionManager, cookie *http.Cookie) *Session {
+	t.Helper()
+
+	s, err := sm.store.Load(context.Background(), cookie.Value)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestRotate(t *testing.T) {
+	alice := db.User{ID: "alice", Email: "alice@example.com", Name: "Alice", Role: "user"}
+	mallory := db.User{ID: "mallory", Email: "mallory@example.com", Name: "Mallory", Role: "user"}
+
+	t.Run("new session", func(t *testing.T) {
+		sm := NewSessionManager(NewMemoryStore(), testOptions)
+		cookie := login(t, sm, alice, nil)
+
+		s := load(t, sm, cookie)
+		if s == nil {
+			t.Fatal("session not saved")
+		}
+		if s.UserID != alice.ID || s.Role != alice.Role {
+			t.Errorf("session = %+v, want alice", s)
+		}
+		if s.Device != "Firefox on Windows" || s.IPAddress != "192.0.2.1" {
+			t.Errorf("device = %q, IP = %q", s.Device, s.IPAddress)
+		}
+		if !cookie.HttpOnly || cookie.MaxAge <= 0 {
+			t.Errorf("cookie = %+v, want HttpOnly with a MaxAge", cookie)
+		}
+	})
+
+	t.Run("same user keeps the login time", func(t *testing.T) {
+		sm := NewSessionManager(NewMemoryStore(), testOptions)
+		first := login(t, sm, alice, nil)
+		createdAt := load(t, sm, first).CreatedAt
+
+		second := login(t, sm, alice, first)
+		if second.Value == first.Value {
+			t.Fatal("Rotate kept the session token")
+		}
+		if load(t, sm, first) != nil {
+			t.Error("the previous session still works")
+		}
+		if s := load(t, sm, second); s == nil || !s.CreatedAt.Equal(createdAt) {
+			t.Errorf("rotated session = %+v, want CreatedAt %s", s, createdAt)
+		}
+	})
+
+	t.Run("planted session is replaced", func(t *testing.T) {
+		sm := NewSessionManager(NewMemoryStore(), testOptions)
+		planted := login(t, sm, mallory, nil)
+		plantedAt := load(t, sm, planted).CreatedAt
+
+		cookie := login(t, sm, alice, planted)
+		if load(t, sm, planted) != nil {
+			t.Error("the planted session still works")
+		}
+		s := load(t, sm, cookie)
+		if s == nil || s.UserID != alice.ID {
+			t.Fatalf("session = %+v, want alice", s)
+		}
+		if s.CreatedAt.Equal(plantedAt) {
+			t.Error("alice's session inherited the planted login time")
+		}
+	})
+
+	t.Run("unknown cookie", func(t *testing.T) {
+		sm := NewSessionManager(NewMemoryStore(), testOptions)
+		cookie := login(t, sm, alice, &http.Cookie{Name: cookieName, Value: "made-up"})
+		if load(t, sm, cookie) == nil {
+			t.Error("session not saved")
+		}
+	})
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	sm := NewSessionManager(NewMemoryStore(), testOptions)
+	alice := db.User{ID: "alice", Role: "user"}
+	bob := db.User{ID: "bob", Role: "user"}
+
+	current := login(t, sm, alice, nil)
+	other := login(t, sm, alice, nil)
+	bobs := login(t, sm, bob, nil)
+
+	if err := sm.RevokeOtherSessions(alice.ID, load(t, sm, current).ID); err != nil {
+		t.Fatal(err)
+	}
+	if load(t, sm, current) == nil {
+		t.Error("the current session was revoked")
+	}
+	if load(t, sm, other) != nil {
+		t.Error("the other session still works")
+	}
+	if load(t, sm, bobs) == nil {
+		t.Error("another user's session was revoked")
+	}
+
+	// Sessions of other users can't be revoked by ID
+	if err := sm.RevokeSession(alice.ID, load(t, sm, bobs).ID); err != nil {
+		t.Fatal(err)
+	}
+	if load(t, sm, bobs) == nil {
+		t.Error("alice revoked bob's session")
+	}
+}
+
+func TestDeviceLabel(t *testing.T) {
+	tests := map[string]string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0 Safari/537.36 Edg/130.0": "Edge on Windows",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15":    "Safari on macOS",
+		"Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0 Mobile Safari/537.36":              "Chrome on Android",
+		"curl/8.0": "curl",
+		"":         "Unknown device",
+	}
+	for ua, want := range tests {
+		if got := DeviceLabel(ua); got != want {
+			t.Errorf("DeviceLabel(%q) = %q, want %q", ua, got, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/nick-friedrich/beesting/app/example-app/db"
+)
+
+// SQLiteStore keeps sessions in the sessions table, under the hash of their
+// token. User details are read with the session in a single query, so
+// role changes apply on the next request.
+type SQLiteStore struct {
+	queries *db.Queries
+}
+
+// NewSQLiteStore creates a store backed by queries
+func NewSQLiteStore(queries *db.Queries) *SQLiteStore {
+	return &SQLiteStore{queries: queries}
+}
+
+func (st *SQLiteStore) Save(ctx context.Context, s *Session) (string, error) {
+	token, err := generateSessionID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	id := hashToken(token)
+
+	_, err = st.queries.CreateSession(ctx, db.CreateSessionParams{
+		ID:             id,
+		UserID:         s.UserID,
+		CreatedAt:      s.CreatedAt.UTC(),
+		ExpiresAt:      s.ExpiresAt.UTC(),
+		LastAccessedAt: s.LastAccessedAt.UTC(),
+		UserAgent:      s.UserAgent,
+		IpAddress:      s.IPAddress,
+		Device:         s.Device,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create session in database: %w", err)
+	}
+
+	s.ID = id
+	return token, nil
+}
+
+func (st *SQLiteStore) Load(ctx context.Context, token string) (*Session, error) {
+	row, err := st.queries.GetSessionWithUser(ctx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return &Session{
+		ID:             row.ID,
+		UserID:         row.UserID,
+		Email:          row.Email,
+		Name:           row.Name,
+		Role:           row.Role,
+		CreatedAt:      row.CreatedAt,
+		ExpiresAt:      row.ExpiresAt,
+		LastAccessedAt: row.LastAccessedAt,
+		UserAgent:      row.UserAgent,
+		IPAddress:      row.IpAddress,
+		Device:         row.Device,
+	}, nil
+}
+
+func (st *SQLiteStore) Touch(ctx context.Context, token string, s *Session) (string, error) {
+	err := st.queries.UpdateSessionAccess(ctx, db.UpdateSessionAccessParams{
+		LastAccessedAt: s.LastAccessedAt.UTC(),
+		ExpiresAt:      s.ExpiresAt.UTC(),
+		ID:             hashToken(token),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to update session: %w", err)
+	}
+	return token, nil
+}
+
+func (st *SQLiteStore) Delete(ctx context.Context, token string) error {
+	return st.queries.DeleteSession(ctx, hashToken(token))
+}
+
+func (st *SQLiteStore) DeleteByID(ctx context.Context, userID, id string) error {
+	return st.queries.DeleteUserSession(ctx, db.DeleteUserSessionParams{ID: id, UserID: userID})
+}
+
+func (st *SQLiteStore) DeleteUser(ctx context.Context, userID string) error {
+	return st.queries.DeleteUserSessions(ctx, userID)
+}
+
+func (st *SQLiteStore) ListUser(ctx context.Context, userID string) ([]Session, error) {
+	rows, err := st.queries.GetUserSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	sessions := make([]Session, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, Session{
+			ID:             row.ID,
+			UserID:         row.UserID,
+			CreatedAt:      row.CreatedAt,
+			ExpiresAt:      row.ExpiresAt,
+			LastAccessedAt: row.LastAccessedAt,
+			UserAgent:      row.UserAgent,
+			IPAddress:      row.IpAddress,
+			Device:         row.Device,
+		})
+	}
+	return sessions, nil
+}
+
+func (st *SQLiteStore) DeleteExpired(ctx context.Context) error {
+	return st.queries.DeleteExpiredSessions(ctx)
+}
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// Store kinds selectable with SESSION_STORE
+const (
+	StoreSQLite = "sqlite"
+	StoreMemory = "memory"
+	StoreCookie = "cookie"
+)
+
+var (
+	// ErrNotFound is returned by Store.Load for unknown and expired sessions
+	ErrNotFound = errors.New("session not found")
+	// ErrNotSupported is returned by stores that can't perform an operation,
+	// like listing the sessions kept in cookies
+	ErrNotSupported = errors.New("not supported by this session store")
+)
+
+// Session is a login as kept by a Store
+type Session struct {
+	ID             string
+	UserID         string
+	Email          string
+	Name           string
+	Role           string
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+	LastAccessedAt time.Time
+	// UserAgent, IPAddress and Device describe where the user logged in
+	UserAgent string
+	IPAddress string
+	Device    string
+}
+
+// Store keeps sessions. The token a store returns from Save is the cookie
+// value that identifies the session on later requests. Stores that keep
+// sessions server side store only a hash of the token as the session ID.
+type Store interface {
+	// Save stores a new session, setting its ID, and returns its token
+	Save(ctx context.Context, s *Session) (string, error)
+	// Load returns the session for token, or ErrNotFound
+	Load(ctx context.Context, token string) (*Session, error)
+	// Touch saves the renewed LastAccessedAt and ExpiresAt of s, the
+	// session for token, and returns the token to use from now on
+	Touch(ctx context.Context, token string, s *Session) (string, error)
+	// Delete removes the session for token
+	Delete(ctx context.Context, token string) error
+	// DeleteByID removes the session with id if it belongs to userID
+	DeleteByID(ctx context.Context, userID, id string) error
+	// DeleteUser removes all sessions of a user
+	DeleteUser(ctx context.Context, userID string) error
+	// ListUser returns the active sessions of a user, most recently used first
+	ListUser(ctx context.Context, userID string) ([]Session, error)
+	// DeleteExpired removes expired sessions
+	DeleteExpired(ctx context.Context) error
+}
+
+// hashToken returns the session ID stored for token. Tokens are 256-bit
+// random values, so a fast unsalted hash is enough to make them unusable.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
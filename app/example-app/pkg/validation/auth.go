@@ -22,21 +22,14 @@ type RegisterForm struct {
 	ConfirmPassword string `json:"confirm_password" form:"confirm_password" validate:"required"`
 }
 
-// ValidateLoginForm validates login form data using the singleton validator
-func ValidateLoginForm(form *LoginForm) error {
-	if Validate == nil {
-		return fmt.Errorf("validator not initialized")
-	}
-	return Validate.Struct(form)
+// ValidateLoginForm validates login form data
+func (v *Validator) ValidateLoginForm(form *LoginForm) error {
+	return v.Struct(form)
 }
 
-// ValidateRegisterForm validates registration form data using the singleton validator
-func ValidateRegisterForm(form *RegisterForm) error {
-	if Validate == nil {
-		return fmt.Errorf("validator not initialized")
-	}
-
-	if err := Validate.Struct(form); err != nil {
+// ValidateRegisterForm validates registration form data
+func (v *Validator) ValidateRegisterForm(form *RegisterForm) error {
+	if err := v.Struct(form); err != nil {
 		return err
 	}
 
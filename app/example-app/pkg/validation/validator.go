@@ -4,15 +4,17 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
-// Global validator instance (singleton pattern)
-var Validate *validator.Validate
+// Validator validates form structs by their validate tags
+type Validator struct {
+	validate *validator.Validate
+}
 
-// InitValidator initializes the global validator instance
-func InitValidator() {
-	Validate = validator.New()
+// NewValidator creates a validator
+func NewValidator() *Validator {
+	return &Validator{validate: validator.New()}
 }
 
-// GetValidator returns the global validator instance
-func GetValidator() *validator.Validate {
-	return Validate
+// Struct validates s by its validate tags
+func (v *Validator) Struct(s any) error {
+	return v.validate.Struct(s)
 }
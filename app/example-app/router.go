@@ -2,63 +2,120 @@ package main
 
 import (
 	"net/http"
+	"net/url"
+	"os"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/gorilla/csrf"
-	"github.com/nick-friedrich/beesting/app/example-app/db"
 	"github.com/nick-friedrich/beesting/app/example-app/handler"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/config"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/deps"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/session"
+	"github.com/nick-friedrich/beesting/pkg/beesting"
 )
 
-func getRouter(queries *db.Queries, csrfKey []byte) *chi.Mux {
-	// Setup router
-	r := chi.NewRouter()
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
+func registerRoutes(r *beesting.App, d *deps.Deps) error {
+	r.Use(beesting.Logger())
+	r.Use(beesting.Recovery())
 
-	// Routes with CSRF protection
-	r.Use(csrf.Protect(csrfKey, csrf.TrustedOrigins([]string{"localhost:3000"}), csrf.FieldName("_csrf")))
+	// Routes with CSRF protection. Keys and origins are read once, changing
+	// them needs a restart.
+	cfg := d.Config.Current()
+	csrfKeys, err := cfg.Secrets.CSRFKeys()
+	if err != nil {
+		return err
+	}
+	r.Use(csrfProtect(csrfKeys, csrf.TrustedOrigins(trustedOrigins(cfg)), csrf.FieldName("_csrf")))
 
-	// Static files (no CSRF needed)
-	r.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.Dir("static/"))))
-
-	r.Get("/", handler.Home())
+	// Static files and the health check are served without loading the
+	// session, so they never touch the session store
+	r.StaticFS("/static", staticFS())
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("OK"))
 	})
 
-	// Posts routes
-	r.Route("/posts", func(r chi.Router) {
-		r.Get("/", handler.ShowPosts(queries))
-		r.Get("/{slug}", handler.ShowPost(queries))
-		r.Get("/new", handler.CreatePostShow())
-		r.Post("/new", handler.CreatePostSubmit(queries))
-		r.Get("/{id}/edit", handler.EditPostShow(queries))
-		r.Post("/{id}/edit", handler.EditPostSubmit(queries))
-		r.Post("/{id}/delete", handler.DeletePostWeb(queries))
-
-		// API disabled because unprotected and unversioned
-		// r.Route("/api", func(r chi.Router) {
-		// 	r.Get("/", handler.ListPosts(queries))
-		// 	r.Post("/", handler.CreatePost(queries))
-		// 	r.Get("/{id}", handler.GetPost(queries))
-		// 	r.Put("/{id}", handler.UpdatePost(queries))
-		// 	r.Delete("/{id}", handler.DeletePost(queries))
-		// 	r.Post("/{id}/publish", handler.PublishPost(queries))
-		// })
+	// Pages get the session for session.FromContext, then feature flags for
+	// flags.On and components.Feature
+	r.Group(func(r chi.Router) {
+		r.Use(d.Sessions.Middleware())
+		r.Use(d.Flags.Middleware())
+
+		r.Get("/", handler.Home(d))
+
+		// Posts routes
+		r.Route("/posts", func(r chi.Router) {
+			r.Get("/", handler.ShowPosts(d))
+			r.Get("/{slug}", handler.ShowPost(d))
+
+			r.Group(func(r chi.Router) {
+				r.Use(session.RequireRole("admin"))
+				r.Get("/new", handler.CreatePostShow(d))
+				r.Post("/new", handler.CreatePostSubmit(d))
+				r.Get("/{id}/edit", handler.EditPostShow(d))
+				r.Post("/{id}/edit", handler.EditPostSubmit(d))
+				r.Post("/{id}/delete", handler.DeletePostWeb(d))
+			})
+
+			// API disabled because unprotected and unversioned
+			// r.Route("/api", func(r chi.Router) {
+			// 	r.Get("/", handler.ListPosts(d))
+			// 	r.Post("/", handler.CreatePost(d))
+			// 	r.Get("/{id}", handler.GetPost(d))
+			// 	r.Put("/{id}", handler.UpdatePost(d))
+			// 	r.Delete("/{id}", handler.DeletePost(d))
+			// 	r.Post("/{id}/publish", handler.PublishPost(d))
+			// })
+		})
+
+		// Admin routes
+		r.Route("/admin/flags", func(r chi.Router) {
+			r.Use(session.RequireRole("admin"))
+			r.Get("/", handler.ShowFlags(d))
+			r.Post("/", handler.SaveFlag(d))
+			r.Post("/{key}/toggle", handler.ToggleFlag(d))
+			r.Post("/{key}/delete", handler.DeleteFlag(d))
+		})
+
+		// Settings routes
+		r.Route("/settings", func(r chi.Router) {
+			r.Use(session.RequireLogin)
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/settings/sessions", http.StatusSeeOther)
+			})
+			r.Get("/sessions", handler.ShowSessions(d))
+			r.Post("/sessions/revoke-others", handler.RevokeOtherSessions(d))
+			r.Post("/sessions/{id}/revoke", handler.RevokeSession(d))
+		})
+
+		// beesting:routes - `beesting generate resource` adds routes above this line
+
+		// Auth routes
+		r.Get("/login", handler.LoginHandler(d))
+		r.Get("/register", handler.RegisterHandler(d))
+		r.Post("/login", handler.LoginSubmitHandler(d))
+		r.Post("/register", handler.RegisterSubmitHandler(d))
+		r.Get("/logout", handler.LogoutHandler(d))
+		r.Get("/verify-email", handler.VerifyEmailHandler(d))
+		r.Post("/resend-confirmation", handler.ResendConfirmationEmailHandler(d))
+
+		// 404 handler for unmatched routes. Set in the group, so 404 pages
+		// see the session too.
+		r.NotFound(handler.NotFound(d))
 	})
 
-	// Auth routes
-	r.Get("/login", handler.LoginHandler())
-	r.Get("/register", handler.RegisterHandler())
-	r.Post("/login", handler.LoginSubmitHandler(queries))
-	r.Post("/register", handler.RegisterSubmitHandler(queries))
-	r.Get("/logout", handler.LogoutHandler())
-	r.Get("/verify-email", handler.VerifyEmailHandler(queries))
-	r.Post("/resend-confirmation", handler.ResendConfirmationEmailHandler(queries))
+	return nil
+}
 
-	// 404 handler for unmatched routes
-	r.NotFound(handler.NotFound())
+// trustedOrigins returns the hosts allowed to submit forms, derived from
+// BaseURL plus the live reload proxy of `beesting dev`
+func trustedOrigins(cfg *config.Config) []string {
+	origins := []string{"localhost:3000"}
+	if baseURL, err := url.Parse(cfg.BaseURL); err == nil && baseURL.Host != "" {
+		origins = []string{baseURL.Host}
+	}
 
-	return r
+	if proxy := os.Getenv("BEESTING_DEV_PROXY"); proxy != "" {
+		origins = append(origins, proxy)
+	}
+	return origins
 }
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nick-friedrich/beesting/app/example-app/db"
+	"github.com/nick-friedrich/beesting/app/example-app/handler"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/deps"
+	"github.com/nick-friedrich/beesting/app/example-app/pkg/session"
+	"github.com/nick-friedrich/beesting/pkg/beesting"
+)
+
+// roles are the user roles the app knows; users get "user" on sign up
+var roles = []string{"user", "admin"}
+
+// registerTasks registers the one-off jobs run with `beesting task example-app <name>`
+func registerTasks(app *beesting.App, d *deps.Deps) {
+	app.Task("sessions:cleanup", "Delete expired sessions", func(ctx context.Context, args []string) error {
+		if inMemorySessions(d) {
+			fmt.Println("⚠️  SESSION_STORE is memory: sessions live in the running app, which tasks can't reach; nothing to clean up")
+			return nil
+		}
+		if err := d.Sessions.CleanupExpiredSessions(); err != nil {
+			return fmt.Errorf("failed to delete expired sessions: %w", err)
+		}
+		fmt.Println("✓ Expired sessions deleted")
+		return nil
+	})
+
+	app.Task("users:promote", "Set a user's role: <email> [user|admin, default admin]", func(ctx context.Context, args []string) error {
+		if len(args) < 1 || len(args) > 2 {
+			return errors.New("usage: users:promote <email> [role]")
+		}
+		role := "admin"
+		if len(args) == 2 {
+			role = args[1]
+		}
+		if !slices.Contains(roles, role) {
+			return fmt.Errorf("unknown role %q, must be one of: %s", role, strings.Join(roles, ", "))
+		}
+
+		user, err := findUserByEmail(ctx, d.Queries, args[0])
+		if err != nil {
+			return err
+		}
+
+		if err := d.Queries.UpdateUserRole(ctx, db.UpdateUserRoleParams{ID: user.ID, Role: role}); err != nil {
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+		fmt.Printf("✓ %s is now %s (was %s)\n", user.Email, role, user.Role)
+
+		// Sessions can't be rotated from here, so end them; the next login
+		// gets a new session ID with the new role
+		if inMemorySessions(d) {
+			fmt.Println("⚠️  SESSION_STORE is memory: sessions live in the running app, which tasks can't reach; existing logins keep the old role until the app restarts")
+			return nil
+		}
+		err = d.Sessions.DeleteUserSessions(user.ID)
+		if errors.Is(err, session.ErrNotSupported) {
+			fmt.Println("⚠️  The session store can't end sessions; existing logins keep the old role until they expire")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to end sessions: %w", err)
+		}
+		fmt.Println("✓ Existing sessions ended, the user needs to log in again")
+		return nil
+	})
+
+	app.Task("users:resend-confirmation", "Send a new email confirmation link: <email>", func(ctx context.Context, args []string) error {
+		if len(args) != 1 {
+			return errors.New("usage: users:resend-confirmation <email>")
+		}
+
+		user, err := findUserByEmail(ctx, d.Queries, args[0])
+		if err != nil {
+			return err
+		}
+		if user.Confirmedat.Valid {
+			return fmt.Errorf("%s already confirmed their email", user.Email)
+		}
+
+		if err := handler.RenewConfirmEmailToken(ctx, d, &user); err != nil {
+			return fmt.Errorf("failed to renew confirmation token: %w", err)
+		}
+		if err := handler.SendConfirmationEmail(d, &user); err != nil {
+			return fmt.Errorf("failed to send confirmation email: %w", err)
+		}
+		fmt.Printf("✓ Confirmation email sent to %s\n", user.Email)
+		return nil
+	})
+}
+
+func findUserByEmail(ctx context.Context, queries *db.Queries, email string) (db.User, error) {
+	user, err := queries.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, fmt.Errorf("no user with email %s", email)
+	}
+	if err != nil {
+		return user, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// inMemorySessions reports whether sessions are kept in memory. A task
+// runs in its own process with an empty store, so it can't change the
+// sessions of the running app.
+func inMemorySessions(d *deps.Deps) bool {
+	return d.Config.Current().SessionConfig.Store == session.StoreMemory
+}
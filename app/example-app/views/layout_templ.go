@@ -103,7 +103,7 @@ func header(session *session.SessionData) templ.Component {
 			return templ_7745c5c3_Err
 		}
 		if session.LoggedIn {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "<li><a class=\"text-base py-2\" href=\"/profile\">Profile</a></li><li><a class=\"text-base py-2\" href=\"/settings\">Settings</a></li><li><hr class=\"my-1\"></li><li><a class=\"text-base py-2 text-error\" href=\"/logout\">Logout</a></li>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "<li><a class=\"text-base py-2\" href=\"/profile\">Profile</a></li><li><a class=\"text-base py-2\" href=\"/settings/sessions\">Devices</a></li><li><hr class=\"my-1\"></li><li><a class=\"text-base py-2 text-error\" href=\"/logout\">Logout</a></li>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -131,17 +131,27 @@ func header(session *session.SessionData) templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</span> <svg xmlns=\"http://www.w3.org/2000/svg\" class=\"h-5 w-5\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M19 9l-7 7-7-7\"></path></svg></div><ul tabindex=\"0\" class=\"menu menu-sm dropdown-content bg-base-100 rounded-box z-50 mt-3 w-52 p-2 shadow-lg\"><!-- <li><a href=\"/profile\" class=\"text-base py-2\">Profile</a></li>\n        <li><a href=\"/settings\" class=\"text-base py-2\">Settings</a></li>\n        <li><hr class=\"my-1\" /></li> --><li><a href=\"/logout\" class=\"text-base py-2 text-error\">Logout</a></li></ul></div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</span> <svg xmlns=\"http://www.w3.org/2000/svg\" class=\"h-5 w-5\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M19 9l-7 7-7-7\"></path></svg></div><ul tabindex=\"0\" class=\"menu menu-sm dropdown-content bg-base-100 rounded-box z-50 mt-3 w-52 p-2 shadow-lg\"><!-- <li><a href=\"/profile\" class=\"text-base py-2\">Profile</a></li>\n        <li><a href=\"/settings\" class=\"text-base py-2\">Settings</a></li>\n        <li><hr class=\"my-1\" /></li> --><li><a href=\"/settings/sessions\" class=\"text-base py-2\">Devices</a></li>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if session.UserRole == "admin" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "<li><a href=\"/admin/flags\" class=\"text-base py-2\">Feature flags</a></li>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "<li><a href=\"/logout\" class=\"text-base py-2 text-error\">Logout</a></li></ul></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		} else {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "<a class=\"btn btn-primary hidden sm:inline-flex\" href=\"/login\">Login</a>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "<a class=\"btn btn-primary hidden sm:inline-flex\" href=\"/login\">Login</a>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "</div></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "</div></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -170,7 +180,7 @@ func footer() templ.Component {
 			templ_7745c5c3_Var5 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "<footer class=\"footer bg-base-300/20 px-4 py-6 sm:px-6 lg:px-8 text-center\"><p class=\"text-sm sm:text-base\">© 2025 BeeSting Starter</p></footer>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "<footer class=\"footer bg-base-300/20 px-4 py-6 sm:px-6 lg:px-8 text-center\"><p class=\"text-sm sm:text-base\">© 2025 BeeSting Starter</p></footer>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// versionPackage is the package whose Version and Commit are set at build time
+const versionPackage = "github.com/nick-friedrich/beesting/pkg/beesting"
+
+// buildVersionPattern matches the versions that can be stamped into a binary.
+// -ldflags is split on spaces and quotes, so those can't appear.
+var buildVersionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+/-]*$`)
+
+var (
+	buildOutput  string
+	buildOS      string
+	buildArch    string
+	buildVersion string
+)
+
+// buildCmd builds a self-contained production binary of an app
+var buildCmd = &cobra.Command{
+	Use:   "build <app>",
+	Short: "Build a production binary of an application",
+	Long: `Build a single, self-contained production binary of an application.
+
+The build regenerates sqlc and templ code, builds minified CSS, embeds
+static/ with that CSS into the binary and stamps it with the version and
+commit (readable as beesting.Version and beesting.Commit). The app's
+static/output.css on disk is left as it is.
+
+Set --os and --arch to cross-compile. Apps using cgo, such as SQLite via
+mattn/go-sqlite3, need a C cross compiler set in CC for that.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appDir, err := findAppDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		absAppDir, err := filepath.Abs(appDir)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		name := filepath.Base(absAppDir)
+
+		goos, goarch := buildOS, buildArch
+		if goos == "" {
+			goos = runtime.GOOS
+		}
+		if goarch == "" {
+			goarch = runtime.GOARCH
+		}
+		crossCompiling := goos != runtime.GOOS || goarch != runtime.GOARCH
+
+		output := buildOutput
+		if output == "" {
+			output = filepath.Join("bin", name)
+			if crossCompiling {
+				output += "-" + goos + "-" + goarch
+			}
+			if goos == "windows" {
+				output += ".exe"
+			}
+		}
+		output, err = filepath.Abs(output)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path: %w", err)
+		}
+
+		version := buildVersion
+		if version == "" {
+			version = gitOutput(appDir, "describe", "--tags", "--always", "--dirty")
+		}
+		if version == "" {
+			version = "dev"
+		}
+		if !buildVersionPattern.MatchString(version) {
+			return fmt.Errorf("invalid version '%s': use letters, digits and . _ + - / only", version)
+		}
+		commit := gitOutput(appDir, "rev-parse", "--short", "HEAD")
+
+		fmt.Printf("🐝 Building %s %s for %s/%s...\n", name, version, goos, goarch)
+
+		if err := runGenerators(appDir); err != nil {
+			return err
+		}
+
+		// The CSS is built into a temporary directory and swapped in for
+		// the app's stylesheet with a go build overlay, so the build
+		// doesn't modify the app's tracked files
+		tmpDir, err := os.MkdirTemp("", "beesting-build-")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		fmt.Println("🔧 Building CSS")
+		var overlay string
+		css := filepath.Join(tmpDir, "output.css")
+		if err := buildCSS(appDir, css, os.Stdout); err != nil {
+			// A stylesheet built earlier is better than no build at all
+			if _, statErr := os.Stat(filepath.Join(appDir, tailwindOutput)); statErr != nil {
+				return err
+			}
+			fmt.Printf("⚠️  %v; using the existing %s\n", err, tailwindOutput)
+		} else if _, err := os.Stat(css); err == nil {
+			if overlay, err = writeOverlay(tmpDir, filepath.Join(absAppDir, tailwindOutput), css); err != nil {
+				return err
+			}
+		}
+
+		if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+
+		ldflags := []string{"-s", "-w", "-X", versionPackage + ".Version=" + version, "-X", versionPackage + ".Commit=" + commit}
+		buildArgs := []string{"build", "-trimpath", "-ldflags", strings.Join(ldflags, " "), "-o", output}
+		if overlay != "" {
+			buildArgs = append(buildArgs, "-overlay", overlay)
+		}
+
+		goBuild := exec.Command("go", append(buildArgs, ".")...)
+		goBuild.Dir = appDir
+		goBuild.Env = append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch)
+		goBuild.Stdout = os.Stdout
+		goBuild.Stderr = os.Stderr
+
+		// Go disables cgo when cross-compiling unless a C compiler is configured
+		if crossCompiling && os.Getenv("CC") == "" {
+			fmt.Println("⚠️  Cross-compiling without CC disables cgo; SQLite via mattn/go-sqlite3 will fail at runtime")
+		}
+
+		fmt.Println("🔧 Compiling")
+		if err := goBuild.Run(); err != nil {
+			return fmt.Errorf("failed to build %s: %w", name, err)
+		}
+
+		info, err := os.Stat(output)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\n✓ Built %s (%.1f MB)\n", output, float64(info.Size())/(1<<20))
+		fmt.Printf("\nThe binary serves static/ from memory. Run it with APP_ENV=production and configure it with PORT, BASE_URL, DB_FILE and MAIL_FROM,\nand the keys CSRF_KEY and SESSION_SECRET (or CSRF_KEY_FILE and SESSION_SECRET_FILE).\n")
+		return nil
+	},
+}
+
+func init() {
+	buildCmd.Flags().StringVarP(&buildOutput, "output", "o", "", "output file (default bin/<app>[-<os>-<arch>])")
+	buildCmd.Flags().StringVar(&buildOS, "os", "", "target operating system (GOOS)")
+	buildCmd.Flags().StringVar(&buildArch, "arch", "", "target architecture (GOARCH)")
+	buildCmd.Flags().StringVar(&buildVersion, "version", "", "version to embed (default git describe)")
+}
+
+// writeOverlay writes a go build -overlay file to dir that replaces the
+// file at path with replacement, and returns its path
+func writeOverlay(dir, path, replacement string) (string, error) {
+	data, err := json.Marshal(map[string]map[string]string{"Replace": {path: replacement}})
+	if err != nil {
+		return "", err
+	}
+	overlay := filepath.Join(dir, "overlay.json")
+	if err := os.WriteFile(overlay, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write build overlay: %w", err)
+	}
+	return overlay, nil
+}
+
+// gitOutput runs a git command in dir and returns its trimmed output, or ""
+// if git is unavailable or the command fails
+func gitOutput(dir string, args ...string) string {
+	gitCmd := exec.Command("git", args...)
+	gitCmd.Dir = dir
+	out, err := gitCmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
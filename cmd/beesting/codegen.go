@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// codeGenerator is an external tool that writes Go code into an app
+type codeGenerator struct {
+	Name    string
+	Args    []string
+	Install string
+	// Applies reports whether the app uses the generator
+	Applies func(appDir string) bool
+}
+
+var codeGenerators = []codeGenerator{
+	{
+		Name:    "templ",
+		Args:    []string{"generate"},
+		Install: "go install github.com/a-h/templ/cmd/templ@latest",
+		Applies: hasTemplFiles,
+	},
+	{
+		Name:    "sqlc",
+		Args:    []string{"generate"},
+		Install: "go install github.com/sqlc-dev/sqlc/cmd/sqlc@latest",
+		Applies: hasSqlcConfig,
+	},
+}
+
+// runGenerators runs every generator the app uses in appDir
+func runGenerators(appDir string) error {
+	return runGeneratorsTo(appDir, os.Stdout)
+}
+
+// runGeneratorsTo runs every generator the app uses in appDir, writing
+// progress and tool output to out
+func runGeneratorsTo(appDir string, out io.Writer) error {
+	for _, gen := range codeGenerators {
+		if !gen.Applies(appDir) {
+			continue
+		}
+
+		fmt.Fprintf(out, "🔧 %s %s\n", gen.Name, strings.Join(gen.Args, " "))
+		if err := gen.run(appDir, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run runs the generator in appDir, writing its output to out
+func (gen codeGenerator) run(appDir string, out io.Writer) error {
+	if _, err := exec.LookPath(gen.Name); err != nil {
+		return fmt.Errorf("%s not found in PATH, install it with: %s", gen.Name, gen.Install)
+	}
+
+	genCmd := exec.Command(gen.Name, gen.Args...)
+	genCmd.Dir = appDir
+	genCmd.Stdout = out
+	genCmd.Stderr = out
+
+	if err := genCmd.Run(); err != nil {
+		return fmt.Errorf("%s generate failed: %w", gen.Name, err)
+	}
+	return nil
+}
+
+// checkGenerated regenerates the app's code in a scratch copy and fails if
+// any file differs from the app's own
+func checkGenerated(appDir string) error {
+	stale, err := staleGenerated(appDir, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	if len(stale) == 0 {
+		fmt.Printf("✓ Generated code in %s is up to date\n", appDir)
+		return nil
+	}
+
+	fmt.Printf("\n⚠️  Generated code in %s is out of date:\n", appDir)
+	for _, p := range stale {
+		fmt.Printf("  %s\n", p)
+	}
+	return fmt.Errorf("generated code is stale, run: beesting generate %s", appDir)
+}
+
+// staleGenerated regenerates the app's code in a scratch copy and returns
+// the generated files that differ from the app's own
+func staleGenerated(appDir string, out io.Writer) ([]string, error) {
+	scratch, err := os.MkdirTemp("", "beesting-check-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	// Apps inside a larger module need its go.mod for templ's version
+	// check, at the same depth below it as in the real tree
+	copyDir := scratch
+	moduleDir, err := enclosingModule(appDir)
+	if err != nil {
+		return nil, err
+	}
+	if moduleDir != "" {
+		absAppDir, err := filepath.Abs(appDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		rel, err := filepath.Rel(moduleDir, absAppDir)
+		if err != nil {
+			return nil, err
+		}
+		if rel != "." {
+			data, err := os.ReadFile(filepath.Join(moduleDir, "go.mod"))
+			if err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(filepath.Join(scratch, "go.mod"), data, 0644); err != nil {
+				return nil, err
+			}
+			copyDir = filepath.Join(scratch, rel)
+		}
+	}
+
+	if err := copyTemplateFS(os.DirFS(appDir), copyDir); err != nil {
+		return nil, fmt.Errorf("failed to copy app: %w", err)
+	}
+
+	if err := runGeneratorsTo(copyDir, out); err != nil {
+		return nil, err
+	}
+
+	return diffTrees(appDir, copyDir)
+}
+
+// diffTrees lists the files that were changed or added in regenerated
+// compared to original. Files skipped when copying an app are ignored.
+func diffTrees(original, regenerated string) ([]string, error) {
+	var changed []string
+
+	err := filepath.WalkDir(regenerated, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(regenerated, p)
+		if err != nil {
+			return err
+		}
+		if skipTemplateEntry(filepath.ToSlash(rel)) {
+			return nil
+		}
+
+		want, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		have, err := os.ReadFile(filepath.Join(original, rel))
+		if err != nil {
+			if os.IsNotExist(err) {
+				changed = append(changed, rel+" (missing)")
+				return nil
+			}
+			return err
+		}
+
+		if !bytes.Equal(have, want) {
+			changed = append(changed, rel)
+		}
+		return nil
+	})
+
+	return changed, err
+}
+
+// hasSqlcConfig reports whether the app has a sqlc configuration file
+func hasSqlcConfig(appDir string) bool {
+	for _, name := range []string{"sqlc.yaml", "sqlc.yml", "sqlc.json"} {
+		if _, err := os.Stat(filepath.Join(appDir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTemplFiles reports whether the app contains any .templ files
+func hasTemplFiles(appDir string) bool {
+	found := false
+	filepath.WalkDir(appDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || found {
+			return fs.SkipAll
+		}
+		if d.IsDir() && d.Name() == "node_modules" {
+			return fs.SkipDir
+		}
+		if strings.HasSuffix(p, ".templ") {
+			found = true
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return found
+}
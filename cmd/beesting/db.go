@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pressly/goose/v3"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultDBFile is the database apps use when DB_FILE is not set
+const defaultDBFile = "./app.db"
+
+var (
+	dbFile string
+	dbYes  bool
+)
+
+// dbCmd groups the database commands
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage an application's database",
+	Long: `Run goose migrations against an application's database.
+
+Migrations are read from the app's db/migrations directory. The app embeds
+the *.sql files there when it is built and applies them on startup, so a
+binary built before the last change to db/migrations may be behind.
+
+The database is taken from --db, or found like the app finds DB_FILE: the
+environment, the app's .env, config.<APP_ENV>.yaml, config.yaml, then
+./app.db. Relative paths are resolved against the app directory.`,
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate <app>",
+	Short: "Apply all pending migrations",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrations(args[0], func(db *sql.DB, dir string) error {
+			return goose.Up(db, dir)
+		})
+	},
+}
+
+var dbRollbackCmd = &cobra.Command{
+	Use:   "rollback <app>",
+	Short: "Roll back the last migration",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrations(args[0], func(db *sql.DB, dir string) error {
+			return goose.Down(db, dir)
+		})
+	},
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status <app>",
+	Short: "Show which migrations have been applied",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrations(args[0], func(db *sql.DB, dir string) error {
+			return goose.Status(db, dir)
+		})
+	},
+}
+
+var dbRedoCmd = &cobra.Command{
+	Use:   "redo <app>",
+	Short: "Roll back the last migration and apply it again",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrations(args[0], func(db *sql.DB, dir string) error {
+			return goose.Redo(db, dir)
+		})
+	},
+}
+
+var dbResetCmd = &cobra.Command{
+	Use:   "reset <app>",
+	Short: "Roll back all migrations",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrations(args[0], func(db *sql.DB, dir string) error {
+			if !dbYes && !confirm("Roll back ALL migrations? This drops the app's data.") {
+				return fmt.Errorf("aborted")
+			}
+			return goose.Reset(db, dir)
+		})
+	},
+}
+
+var dbUpToCmd = &cobra.Command{
+	Use:   "up-to <app> <version>",
+	Short: "Apply migrations up to and including a version",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, err := parseVersion(args[1])
+		if err != nil {
+			return err
+		}
+		return withMigrations(args[0], func(db *sql.DB, dir string) error {
+			return goose.UpTo(db, dir, version)
+		})
+	},
+}
+
+var dbDownToCmd = &cobra.Command{
+	Use:   "down-to <app> <version>",
+	Short: "Roll back migrations newer than a version (0 rolls back everything)",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, err := parseVersion(args[1])
+		if err != nil {
+			return err
+		}
+		return withMigrations(args[0], func(db *sql.DB, dir string) error {
+			if version == 0 && !dbYes && !confirm("Roll back ALL migrations? This drops the app's data.") {
+				return fmt.Errorf("aborted")
+			}
+			return goose.DownTo(db, dir, version)
+		})
+	},
+}
+
+var dbCreateCmd = &cobra.Command{
+	Use:   "create <app> <name>",
+	Short: "Create a new SQL migration",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appDir, err := findAppDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		dir := filepath.Join(appDir, "db", "migrations")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create migrations directory: %w", err)
+		}
+
+		goose.SetBaseFS(nil)
+		if err := goose.Create(nil, dir, args[1], "sql"); err != nil {
+			return fmt.Errorf("failed to create migration: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	dbCmd.PersistentFlags().StringVar(&dbFile, "db", "", "database file (default DB_FILE from the app's config, or ./app.db)")
+	dbResetCmd.Flags().BoolVarP(&dbYes, "yes", "y", false, "don't ask for confirmation")
+	dbDownToCmd.Flags().BoolVarP(&dbYes, "yes", "y", false, "don't ask for confirmation when rolling back to 0")
+
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbRollbackCmd)
+	dbCmd.AddCommand(dbStatusCmd)
+	dbCmd.AddCommand(dbRedoCmd)
+	dbCmd.AddCommand(dbResetCmd)
+	dbCmd.AddCommand(dbUpToCmd)
+	dbCmd.AddCommand(dbDownToCmd)
+	dbCmd.AddCommand(dbCreateCmd)
+}
+
+// withMigrations opens an app's database and runs fn with goose set up to
+// read the app's migrations from the returned directory. These are the
+// source files of the set the app embeds (db/migrations/*.sql), read from
+// disk since the CLI can't reach into the app's binary.
+func withMigrations(name string, fn func(db *sql.DB, dir string) error) error {
+	appDir, err := findAppDir(name)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(appDir, "db", "migrations")
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("app '%s' has no db/migrations directory", name)
+	}
+
+	dbPath := appDBPath(appDir)
+	fmt.Printf("🐝 Database: %s\n", dbPath)
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	goose.SetBaseFS(os.DirFS(dir))
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		return err
+	}
+
+	return fn(db, ".")
+}
+
+// appDBPath returns the database file an app uses. Relative paths are
+// resolved against the app directory, where the app runs.
+func appDBPath(appDir string) string {
+	dbPath := dbFile
+	if dbPath == "" {
+		dbPath = configDBFile(appDir)
+		if !filepath.IsAbs(dbPath) && !strings.Contains(dbPath, ":memory:") {
+			dbPath = filepath.Join(appDir, dbPath)
+		}
+	}
+	return dbPath
+}
+
+// configDBFile returns DB_FILE from the app's config, looked up in the
+// order of the app's config.Load
+func configDBFile(appDir string) string {
+	if value, ok := lookupAppEnv(appDir, "DB_FILE"); ok && value != "" {
+		return value
+	}
+
+	env, ok := lookupAppEnv(appDir, "APP_ENV")
+	if !ok || env == "" {
+		env = "development"
+	}
+	for _, name := range []string{"config." + env + ".yaml", "config.yaml"} {
+		var cfg struct {
+			DBFile string `yaml:"db_file"`
+		}
+		data, err := os.ReadFile(filepath.Join(appDir, name))
+		if err == nil && yaml.Unmarshal(data, &cfg) == nil && cfg.DBFile != "" {
+			return cfg.DBFile
+		}
+	}
+
+	// The test profile keeps its database in memory
+	if env == "test" {
+		return ":memory:"
+	}
+	return defaultDBFile
+}
+
+// parseVersion parses a migration version such as 20251011153130
+func parseVersion(s string) (int64, error) {
+	version, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid migration version '%s'", s)
+	}
+	return version, nil
+}
+
+// confirm asks a yes/no question on the terminal. Without a terminal it
+// returns false so destructive commands need an explicit --yes.
+func confirm(question string) bool {
+	if !isTerminal(os.Stdin) {
+		fmt.Printf("⚠️  %s Pass --yes to confirm.\n", question)
+		return false
+	}
+
+	fmt.Printf("⚠️  %s [y/N]: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppDBPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		env   map[string]string
+		flag  string
+		want  string
+	}{
+		{
+			name: "default",
+			want: "app.db",
+		},
+		{
+			name:  "config.yaml",
+			files: map[string]string{"config.yaml": "port: \"3000\"\ndb_file: ./data/blog.db\n"},
+			want:  "data/blog.db",
+		},
+		{
+			name: "profile file over config.yaml",
+			files: map[string]string{
+				"config.yaml":             "db_file: ./blog.db\n",
+				"config.development.yaml": "db_file: ./dev.db\n",
+				"config.production.yaml":  "db_file: ./prod.db\n",
+			},
+			want: "dev.db",
+		},
+		{
+			name: "APP_ENV selects the profile file",
+			files: map[string]string{
+				"config.yaml":            "db_file: ./blog.db\n",
+				"config.production.yaml": "db_file: ./prod.db\n",
+				".env":                   "APP_ENV=production\n",
+			},
+			want: "prod.db",
+		},
+		{
+			name:  "test profile",
+			files: map[string]string{".env": "APP_ENV=test\n"},
+			want:  ":memory:",
+		},
+		{
+			name: ".env over config files",
+			files: map[string]string{
+				"config.yaml": "db_file: ./blog.db\n",
+				".env":        "DB_FILE=./env.db\n",
+			},
+			want: "env.db",
+		},
+		{
+			name:  "environment over .env",
+			files: map[string]string{".env": "DB_FILE=./env.db\n"},
+			env:   map[string]string{"DB_FILE": "/var/lib/blog.db"},
+			want:  "/var/lib/blog.db",
+		},
+		{
+			name:  "--db over everything",
+			files: map[string]string{".env": "DB_FILE=./env.db\n"},
+			flag:  "/tmp/flag.db",
+			want:  "/tmp/flag.db",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appDir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(appDir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("APP_ENV", "")
+			t.Setenv("DB_FILE", "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			dbFile = tt.flag
+			defer func() { dbFile = "" }()
+
+			want := tt.want
+			if !filepath.IsAbs(want) && want != ":memory:" {
+				want = filepath.Join(appDir, want)
+			}
+			if got := appDBPath(appDir); got != want {
+				t.Errorf("appDBPath() = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/modfile"
+)
+
+// doctorCmd diagnoses the toolchain and, optionally, an app
+var doctorCmd = &cobra.Command{
+	Use:   "doctor [app]",
+	Short: "Check the toolchain and an application for common problems",
+	Long: `Check that the tools beesting uses are installed and reachable, and
+with an app name also check the app's structure, generated code and
+migrations. Every problem comes with a suggested fix.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report := &doctorReport{}
+
+		fmt.Println("🐝 Toolchain")
+		checkToolchain(report)
+
+		if len(args) == 1 {
+			appDir, err := findAppDir(args[0])
+			if err != nil {
+				report.fail(err.Error(), "")
+			} else {
+				fmt.Printf("\n🐝 App %s\n", appDir)
+				checkApp(report, appDir)
+			}
+		}
+
+		fmt.Println()
+		if report.problems > 0 {
+			return fmt.Errorf("found %d problem(s) and %d warning(s)", report.problems, report.warnings)
+		}
+		if report.warnings > 0 {
+			fmt.Printf("✓ No problems, %d warning(s)\n", report.warnings)
+			return nil
+		}
+		fmt.Println("✓ Everything looks good")
+		return nil
+	},
+}
+
+// doctorReport prints check results and counts what went wrong
+type doctorReport struct {
+	problems int
+	warnings int
+}
+
+func (r *doctorReport) ok(format string, args ...any) {
+	fmt.Printf("  ✓ %s\n", fmt.Sprintf(format, args...))
+}
+
+// warn reports something that may cause trouble later
+func (r *doctorReport) warn(msg, fix string) {
+	r.warnings++
+	fmt.Printf("  ⚠️  %s\n", msg)
+	if fix != "" {
+		fmt.Printf("     → %s\n", fix)
+	}
+}
+
+// fail reports something that stops beesting or the app from working
+func (r *doctorReport) fail(msg, fix string) {
+	r.problems++
+	fmt.Printf("  ✗ %s\n", msg)
+	if fix != "" {
+		fmt.Printf("     → %s\n", fix)
+	}
+}
+
+// doctorTool describes a command line tool and how to install it
+type doctorTool struct {
+	Name        string
+	VersionArgs []string
+	Install     string
+	// Required tools are needed by every app; the others are optional
+	Required bool
+	// Obsolete tools were used by earlier beesting versions and aren't needed anymore
+	Obsolete bool
+	// Note explains what an optional or obsolete tool is for
+	Note string
+}
+
+var doctorTools = []doctorTool{
+	{Name: "go", VersionArgs: []string{"version"}, Install: "https://go.dev/doc/install", Required: true},
+	{Name: "sqlc", VersionArgs: []string{"version"}, Install: "go install github.com/sqlc-dev/sqlc/cmd/sqlc@latest", Required: true},
+	{Name: "templ", VersionArgs: []string{"version"}, Install: "go install github.com/a-h/templ/cmd/templ@latest", Required: true},
+	{Name: "node", VersionArgs: []string{"--version"}, Install: "https://nodejs.org", Note: "needed for apps with a package.json (Tailwind)"},
+	{Name: "npm", VersionArgs: []string{"--version"}, Install: "https://nodejs.org", Note: "needed for apps with a package.json (Tailwind)"},
+	{Name: "goose", VersionArgs: []string{"--version"}, Install: "go install github.com/pressly/goose/v3/cmd/goose@latest", Obsolete: true, Note: "not needed, use beesting db"},
+	{Name: "air", VersionArgs: []string{"-v"}, Obsolete: true, Note: "not needed, beesting dev has its own watcher"},
+}
+
+var versionPattern = regexp.MustCompile(`v?\d+\.\d+(\.\d+)?`)
+
+// checkToolchain checks tool presence, versions and PATH setup
+func checkToolchain(report *doctorReport) {
+	for _, tool := range doctorTools {
+		path, err := exec.LookPath(tool.Name)
+		if err != nil {
+			switch {
+			case tool.Required:
+				report.fail(tool.Name+" not found in PATH", "install it: "+tool.Install)
+			case tool.Obsolete:
+				report.ok("%s not installed (%s)", tool.Name, tool.Note)
+			default:
+				report.warn(tool.Name+" not found in PATH ("+tool.Note+")", "install it: "+tool.Install)
+			}
+			continue
+		}
+
+		version := toolVersion(path, tool.VersionArgs)
+		if tool.Obsolete {
+			report.ok("%s %s (%s)", tool.Name, version, tool.Note)
+			continue
+		}
+		report.ok("%s %s", tool.Name, version)
+
+		if tool.Name == "templ" {
+			checkTemplVersion(report, version)
+		}
+	}
+
+	checkGoBinInPath(report)
+}
+
+// toolVersion runs a tool's version command and extracts the version number
+func toolVersion(path string, args []string) string {
+	out, err := exec.Command(path, args...).CombinedOutput()
+	if err != nil {
+		return "(unknown version)"
+	}
+	if match := versionPattern.FindString(string(out)); match != "" {
+		return match
+	}
+	return "(unknown version)"
+}
+
+// checkTemplVersion compares the templ CLI with the templ module the code
+// is built against; mismatches produce generated code that doesn't compile
+func checkTemplVersion(report *doctorReport, cliVersion string) {
+	required := requiredModuleVersion("go.mod", "github.com/a-h/templ")
+	if required == "" || cliVersion == "(unknown version)" {
+		return
+	}
+
+	if strings.TrimPrefix(cliVersion, "v") != strings.TrimPrefix(required, "v") {
+		report.warn(
+			fmt.Sprintf("templ CLI %s differs from github.com/a-h/templ %s in go.mod", cliVersion, required),
+			"go install github.com/a-h/templ/cmd/templ@"+required,
+		)
+	}
+}
+
+// requiredModuleVersion returns the version of modulePath required by a go.mod file
+func requiredModuleVersion(goModPath, modulePath string) string {
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return ""
+	}
+	f, err := modfile.ParseLax(goModPath, data, nil)
+	if err != nil {
+		return ""
+	}
+	for _, req := range f.Require {
+		if req.Mod.Path == modulePath {
+			return req.Mod.Version
+		}
+	}
+	return ""
+}
+
+// checkGoBinInPath checks that tools installed with `go install` can be found
+func checkGoBinInPath(report *doctorReport) {
+	if _, err := exec.LookPath("go"); err != nil {
+		return
+	}
+
+	goBin := goEnv("GOBIN")
+	if goBin == "" {
+		if goPath := goEnv("GOPATH"); goPath != "" {
+			goBin = filepath.Join(strings.Split(goPath, string(os.PathListSeparator))[0], "bin")
+		}
+	}
+	if goBin == "" {
+		return
+	}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if filepath.Clean(dir) == filepath.Clean(goBin) {
+			report.ok("%s is in PATH", goBin)
+			return
+		}
+	}
+
+	report.warn(
+		goBin+" is not in PATH, so tools installed with `go install` (including beesting) are not found",
+		fmt.Sprintf("add `export PATH=\"$PATH:%s\"` to your ~/.zshrc or ~/.bashrc and open a new terminal", goBin),
+	)
+}
+
+// goEnv returns a variable from `go env`
+func goEnv(key string) string {
+	out, err := exec.Command("go", "env", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// checkApp checks an app's structure, generated code and migrations
+func checkApp(report *doctorReport, appDir string) {
+	report.ok("main.go")
+
+	usesDB := false
+	if _, err := os.Stat(filepath.Join(appDir, "db")); err == nil {
+		usesDB = true
+	}
+
+	if hasSqlcConfig(appDir) {
+		report.ok("sqlc.yaml")
+	} else if usesDB {
+		report.warn("db/ exists but there is no sqlc.yaml", "add a sqlc.yaml, see app/example-app/sqlc.yaml")
+	}
+
+	migrationsDir := filepath.Join(appDir, "db", "migrations")
+	hasMigrations := false
+	if info, err := os.Stat(migrationsDir); err == nil && info.IsDir() {
+		hasMigrations = true
+		report.ok("db/migrations")
+	} else if usesDB {
+		report.warn("db/migrations is missing", "create the first migration: beesting db create "+appDir+" init")
+	}
+
+	if _, err := os.Stat(filepath.Join(appDir, ".env")); err != nil {
+		if _, err := os.Stat(filepath.Join(appDir, ".env.tmpl")); err == nil {
+			report.warn(".env is missing, the app runs with its built-in defaults", "copy .env.tmpl to .env and fill in the values")
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(appDir, "package.json")); err == nil {
+		if _, err := os.Stat(filepath.Join(appDir, "node_modules")); err != nil {
+			report.warn("node_modules is missing, CSS can't be rebuilt", "cd "+appDir+" && npm install")
+		} else {
+			report.ok("node_modules")
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(appDir, tailwindInput)); err == nil {
+		if _, err := os.Stat(filepath.Join(appDir, tailwindOutput)); err != nil {
+			report.fail(tailwindOutput+" is missing, pages will be unstyled", "cd "+appDir+" && npm run build-css")
+		}
+	}
+
+	checkGeneratedCode(report, appDir)
+
+	if hasMigrations {
+		checkPendingMigrations(report, appDir, migrationsDir)
+	}
+}
+
+// checkGeneratedCode reports generated files that are out of date
+func checkGeneratedCode(report *doctorReport, appDir string) {
+	for _, gen := range codeGenerators {
+		if !gen.Applies(appDir) {
+			continue
+		}
+		if _, err := exec.LookPath(gen.Name); err != nil {
+			report.warn("can't check generated code without "+gen.Name, "install it: "+gen.Install)
+			return
+		}
+	}
+
+	stale, err := staleGenerated(appDir, io.Discard)
+	if err != nil {
+		report.fail("generating code failed: "+err.Error(), "run `beesting generate "+appDir+"` to see the error")
+		return
+	}
+	if len(stale) > 0 {
+		report.fail(
+			fmt.Sprintf("generated code is stale: %s", strings.Join(stale, ", ")),
+			"beesting generate "+appDir,
+		)
+		return
+	}
+	report.ok("generated code is up to date")
+}
+
+// checkPendingMigrations reports migrations not yet applied to the app's database
+func checkPendingMigrations(report *doctorReport, appDir, migrationsDir string) {
+	dbPath := appDBPath(appDir)
+	if _, err := os.Stat(dbPath); err != nil {
+		report.ok("database %s not created yet, the app creates it on start", dbPath)
+		return
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		report.fail("can't open "+dbPath+": "+err.Error(), "")
+		return
+	}
+	defer db.Close()
+
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, os.DirFS(migrationsDir))
+	if err != nil {
+		report.fail("can't read migrations: "+err.Error(), "")
+		return
+	}
+
+	statuses, err := provider.Status(context.Background())
+	if err != nil {
+		report.fail("can't read migration status of "+dbPath+": "+err.Error(), "")
+		return
+	}
+
+	var pending []string
+	for _, status := range statuses {
+		if status.State == goose.StatePending {
+			pending = append(pending, filepath.Base(status.Source.Path))
+		}
+	}
+
+	if len(pending) > 0 {
+		report.warn(
+			fmt.Sprintf("%d pending migration(s) in %s: %s", len(pending), dbPath, strings.Join(pending, ", ")),
+			"beesting db migrate "+appDir+" (or start the app)",
+		)
+		return
+	}
+	report.ok("database %s is fully migrated", dbPath)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nick-friedrich/beesting/pkg/beesting"
+)
+
+// appEnv returns the current environment extended with the variables in the
+// app's .env file. Variables already set in the environment take precedence.
+func appEnv(appDir string) []string {
+	env := os.Environ()
+
+	vars, err := beesting.ReadEnvFile(filepath.Join(appDir, ".env"))
+	if err != nil {
+		return env
+	}
+
+	for _, kv := range vars {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, set := os.LookupEnv(key); !set {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// lookupAppEnv returns a variable from the environment or, failing that,
+// from the app's .env file. Empty variables are unset, as in the app's
+// config.
+func lookupAppEnv(appDir, key string) (string, bool) {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value, true
+	}
+
+	vars, err := beesting.ReadEnvFile(filepath.Join(appDir, ".env"))
+	if err != nil {
+		return "", false
+	}
+	for _, kv := range vars {
+		if k, value, _ := strings.Cut(kv, "="); k == key {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+// resourceTemplates holds the files `generate resource` renders. They use
+// [[ ]] delimiters so templ and Go braces need no escaping.
+//
+//go:embed generators/resource/*.tmpl
+var resourceTemplates embed.FS
+
+// routesMarker is the comment in router.go above which resource routes are inserted
+const routesMarker = "// beesting:routes"
+
+var (
+	generateApp   string
+	generateCheck bool
+)
+
+// generateCmd runs an app's code generators and groups the scaffolders
+var generateCmd = &cobra.Command{
+	Use:     "generate <app>",
+	Aliases: []string{"gen"},
+	Short:   "Generate code for an application",
+	Long: `Run sqlc and templ for an application.
+
+With --check nothing is written; the command fails if the committed
+generated code is out of date with db/queries, db/schemas or the .templ files.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appDir, err := findAppDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		if generateCheck {
+			return checkGenerated(appDir)
+		}
+
+		if err := runGenerators(appDir); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Generated code for %s\n", appDir)
+		return nil
+	},
+}
+
+// generateResourceCmd scaffolds a CRUD resource modelled on posts
+var generateResourceCmd = &cobra.Command{
+	Use:   "resource <name> <field:type>...",
+	Short: "Generate a CRUD resource (migration, queries, handlers, views and routes)",
+	Long: `Generate a CRUD resource modelled on the posts feature of example-app.
+
+Field types: string, text, int, float, bool, time
+
+Example:
+  beesting generate resource comment author:string body:text rating:int --app my-blog
+
+This creates:
+  db/migrations/<timestamp>_create_comments.sql
+  db/schemas/comments.sql
+  db/queries/comments.sql
+  handler/comment.go
+  views/comments/{index,show,new,edit}.templ
+and adds the routes to router.go.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appDir, err := findAppDir(generateApp)
+		if err != nil {
+			return err
+		}
+
+		data, err := newResourceData(args[0], args[1:])
+		if err != nil {
+			return err
+		}
+		data.Module = appModulePath(appDir)
+
+		return generateResource(appDir, data)
+	},
+}
+
+func init() {
+	generateCmd.Flags().BoolVar(&generateCheck, "check", false, "fail if generated code is out of date instead of writing it")
+
+	generateResourceCmd.Flags().StringVar(&generateApp, "app", "", "app to generate into (name in app/ or path)")
+	generateResourceCmd.MarkFlagRequired("app")
+	generateCmd.AddCommand(generateResourceCmd)
+}
+
+// findAppDir resolves an app name (in app/) or a path to an app directory
+func findAppDir(name string) (string, error) {
+	candidates := []string{filepath.Join("app", name), name}
+	for _, dir := range candidates {
+		if _, err := os.Stat(filepath.Join(dir, "main.go")); err == nil {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("app '%s' does not exist. Create it with: beesting new %s", name, name)
+}
+
+// resourceData is passed to the resource templates
+type resourceData struct {
+	Module       string
+	Name         string // snake_case singular, e.g. blog_post
+	Table        string // snake_case plural, e.g. blog_posts
+	URLPath      string // kebab-case plural, e.g. blog-posts
+	GoName       string // BlogPost
+	GoPlural     string // BlogPosts
+	VarName      string // blogPost
+	VarPlural    string // blogPosts
+	Label        string // blog post
+	LabelPlural  string // blog posts
+	ViewsPackage string // blogpostviews
+	Fields       []resourceField
+}
+
+// resourceField is a column of the generated table
+type resourceField struct {
+	Column  string // snake_case column name
+	GoName  string // field name sqlc generates for the column
+	Label   string
+	Type    string
+	SQLType string
+	GoType  string
+}
+
+// formData is passed to the shared "fields" template
+type formData struct {
+	VarName string
+	Edit    bool
+	Fields  []resourceField
+}
+
+var fieldTypes = map[string]struct{ sql, goType, input string }{
+	"string": {"TEXT", "string", "text"},
+	"text":   {"TEXT", "string", ""},
+	"int":    {"INTEGER", "int64", "number"},
+	"float":  {"REAL", "float64", "number"},
+	"bool":   {"BOOLEAN", "bool", "checkbox"},
+	"time":   {"DATETIME", "time.Time", "datetime-local"},
+}
+
+// reservedNames collide with identifiers used by the generated code
+var reservedNames = map[string]bool{
+	"db": true, "session": true, "views": true, "handler": true, "http": true,
+	"fmt": true, "components": true, "chi": true, "strconv": true, "time": true,
+	"form": true, "err": true, "r": true, "w": true, "d": true, "deps": true,
+}
+
+// reservedColumns are added to every generated table
+var reservedColumns = map[string]bool{"id": true, "created_at": true, "updated_at": true}
+
+// identPattern matches resource and field names in CamelCase, snake_case
+// or kebab-case
+var identPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*([_-][a-zA-Z0-9]+)*$`)
+
+func newResourceData(name string, fieldArgs []string) (*resourceData, error) {
+	if !identPattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid resource name '%s': use letters, digits, - and _", name)
+	}
+
+	singular := toSnake(name)
+	if reservedNames[singular] {
+		return nil, fmt.Errorf("'%s' is reserved, choose another resource name", name)
+	}
+	plural := pluralize(singular)
+
+	data := &resourceData{
+		Name:         singular,
+		Table:        plural,
+		URLPath:      strings.ReplaceAll(plural, "_", "-"),
+		GoName:       toGoName(singular),
+		GoPlural:     toGoName(plural),
+		Label:        strings.ReplaceAll(singular, "_", " "),
+		LabelPlural:  strings.ReplaceAll(plural, "_", " "),
+		ViewsPackage: strings.ReplaceAll(singular, "_", "") + "views",
+	}
+	data.VarName = lowerFirst(data.GoName)
+	data.VarPlural = lowerFirst(data.GoPlural)
+	if data.VarName == data.VarPlural {
+		data.VarPlural += "List"
+	}
+
+	seen := map[string]bool{}
+	for _, arg := range fieldArgs {
+		fieldName, fieldType, ok := strings.Cut(arg, ":")
+		if !ok {
+			fieldType = "string"
+		}
+
+		if !identPattern.MatchString(fieldName) {
+			return nil, fmt.Errorf("invalid field name '%s': use letters, digits, - and _", fieldName)
+		}
+
+		column := toSnake(fieldName)
+		if reservedColumns[column] {
+			return nil, fmt.Errorf("field '%s' is added automatically", column)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("duplicate field '%s'", column)
+		}
+		seen[column] = true
+
+		t, ok := fieldTypes[fieldType]
+		if !ok {
+			return nil, fmt.Errorf("unknown type '%s' for field '%s' (expected string, text, int, float, bool or time)", fieldType, fieldName)
+		}
+
+		data.Fields = append(data.Fields, resourceField{
+			Column:  column,
+			GoName:  toGoName(column),
+			Label:   strings.ReplaceAll(column, "_", " "),
+			Type:    fieldType,
+			SQLType: t.sql,
+			GoType:  t.goType,
+		})
+	}
+
+	return data, nil
+}
+
+// ColumnList returns the comma-separated field columns
+func (d *resourceData) ColumnList() string {
+	columns := make([]string, len(d.Fields))
+	for i, f := range d.Fields {
+		columns[i] = f.Column
+	}
+	return strings.Join(columns, ", ")
+}
+
+// Placeholders returns one ? per field
+func (d *resourceData) Placeholders() string {
+	return strings.TrimSuffix(strings.Repeat("?, ", len(d.Fields)), ", ")
+}
+
+// HasTime reports whether any field is a time
+func (d *resourceData) HasTime() bool {
+	return d.hasType("time")
+}
+
+// HasNumberFields reports whether any field is an int or float
+func (d *resourceData) HasNumberFields() bool {
+	return d.hasType("int") || d.hasType("float")
+}
+
+// HasParsedFields reports whether parsing the form can fail on a conversion
+func (d *resourceData) HasParsedFields() bool {
+	return d.HasNumberFields() || d.HasTime()
+}
+
+func (d *resourceData) hasType(t string) bool {
+	for _, f := range d.Fields {
+		if f.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Form returns the data for the new form
+func (d *resourceData) Form() formData {
+	return formData{VarName: d.VarName, Fields: d.Fields}
+}
+
+// EditForm returns the data for the edit form
+func (d *resourceData) EditForm() formData {
+	return formData{VarName: d.VarName, Edit: true, Fields: d.Fields}
+}
+
+// InputType returns the HTML input type for the field
+func (f resourceField) InputType() string {
+	return fieldTypes[f.Type].input
+}
+
+// Display returns a templ expression that renders the field of v as text
+func (f resourceField) Display(v string) string {
+	switch f.Type {
+	case "string", "text":
+		return v + "." + f.GoName
+	case "time":
+		return v + "." + f.GoName + `.Format("January 2, 2006 at 3:04 PM")`
+	default:
+		return "fmt.Sprint(" + v + "." + f.GoName + ")"
+	}
+}
+
+// FormValue returns a templ expression for the field's input value
+func (f resourceField) FormValue(v string) string {
+	switch f.Type {
+	case "int":
+		return "strconv.FormatInt(" + v + "." + f.GoName + ", 10)"
+	case "float":
+		return "strconv.FormatFloat(" + v + "." + f.GoName + ", 'f', -1, 64)"
+	case "time":
+		return v + "." + f.GoName + `.Format("2006-01-02T15:04")`
+	default:
+		return v + "." + f.GoName
+	}
+}
+
+// generateResource writes the resource files into appDir and registers its routes
+func generateResource(appDir string, data *resourceData) error {
+	if _, err := os.Stat(filepath.Join(appDir, "sqlc.yaml")); err != nil {
+		return fmt.Errorf("app has no sqlc.yaml; resources need the db setup of example-app")
+	}
+
+	tmpl, err := template.New("resource").
+		Delims("[[", "]]").
+		Funcs(template.FuncMap{"title": upperFirst}).
+		ParseFS(resourceTemplates, "generators/resource/*.tmpl")
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().UTC().Format("20060102150405")
+	files := []struct{ template, path string }{
+		{"migration.sql.tmpl", filepath.Join("db", "migrations", timestamp+"_create_"+data.Table+".sql")},
+		{"schema.sql.tmpl", filepath.Join("db", "schemas", data.Table+".sql")},
+		{"queries.sql.tmpl", filepath.Join("db", "queries", data.Table+".sql")},
+		{"handler.go.tmpl", filepath.Join("handler", data.Name+".go")},
+		{"index.templ.tmpl", filepath.Join("views", data.URLPath, "index.templ")},
+		{"show.templ.tmpl", filepath.Join("views", data.URLPath, "show.templ")},
+		{"new.templ.tmpl", filepath.Join("views", data.URLPath, "new.templ")},
+		{"edit.templ.tmpl", filepath.Join("views", data.URLPath, "edit.templ")},
+	}
+
+	// Refuse to overwrite anything before writing the first file
+	for _, f := range files[1:] {
+		if _, err := os.Stat(filepath.Join(appDir, f.path)); err == nil {
+			return fmt.Errorf("%s already exists", filepath.Join(appDir, f.path))
+		}
+	}
+
+	for _, f := range files {
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, f.template, data); err != nil {
+			return err
+		}
+
+		content := buf.Bytes()
+		if strings.HasSuffix(f.path, ".go") {
+			formatted, err := format.Source(content)
+			if err != nil {
+				return fmt.Errorf("generated %s does not compile: %w", f.path, err)
+			}
+			content = formatted
+		}
+
+		target := filepath.Join(appDir, f.path)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("  create %s\n", target)
+	}
+
+	var routes bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&routes, "routes.go.tmpl", data); err != nil {
+		return err
+	}
+
+	routerPath := filepath.Join(appDir, "router.go")
+	if err := insertRoutes(routerPath, routes.String()); err != nil {
+		fmt.Printf("\n⚠️  Could not add routes to %s (%v). Add them by hand:\n\n%s\n", routerPath, err, routes.String())
+	} else {
+		fmt.Printf("  update %s\n", routerPath)
+	}
+
+	fmt.Printf("\n✓ Generated resource: %s\n\n", data.Name)
+
+	if err := runGenerators(appDir); err != nil {
+		fmt.Printf("\n⚠️  %v\n", err)
+		fmt.Printf("\nNext steps:\n")
+		fmt.Printf("  beesting generate %s\n", appDir)
+	}
+
+	return nil
+}
+
+// insertRoutes adds routes to router.go above the routes marker, formatted
+// so they take the indentation of the block the marker is in
+func insertRoutes(routerPath, routes string) error {
+	content, err := os.ReadFile(routerPath)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), routesMarker) {
+			updated := []byte(strings.Join(lines[:i], "") + routes + strings.Join(lines[i:], ""))
+			formatted, err := format.Source(updated)
+			if err != nil {
+				return fmt.Errorf("router.go does not compile with the new routes: %w", err)
+			}
+			return os.WriteFile(routerPath, formatted, 0644)
+		}
+	}
+
+	return fmt.Errorf("no '%s' marker found", routesMarker)
+}
+
+// toSnake converts CamelCase or kebab-case to snake_case
+func toSnake(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if r == '-' {
+			r = '_'
+		}
+		if unicode.IsUpper(r) {
+			if i > 0 && s[i-1] != '_' && s[i-1] != '-' {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// toGoName converts snake_case to the CamelCase name sqlc generates
+func toGoName(s string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(s, "_") {
+		if part == "id" {
+			b.WriteString("ID")
+			continue
+		}
+		b.WriteString(upperFirst(part))
+	}
+	return b.String()
+}
+
+// pluralize returns a naive English plural of a snake_case name
+func pluralize(s string) string {
+	switch {
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	default:
+		return s + "s"
+	}
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
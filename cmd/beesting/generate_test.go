@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewResourceDataNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantTable string
+		wantPath  string
+		wantGo    string
+		wantError string
+	}{
+		{name: "comment", wantTable: "comments", wantPath: "comments", wantGo: "Comment"},
+		{name: "BlogPost", wantTable: "blog_posts", wantPath: "blog-posts", wantGo: "BlogPost"},
+		{name: "blog_post", wantTable: "blog_posts", wantPath: "blog-posts", wantGo: "BlogPost"},
+		{name: "blog-post", wantTable: "blog_posts", wantPath: "blog-posts", wantGo: "BlogPost"},
+		{name: "category", wantTable: "categories", wantPath: "categories", wantGo: "Category"},
+		{name: "post2", wantTable: "post2s", wantPath: "post2s", wantGo: "Post2"},
+		{name: "2post", wantError: "invalid resource name"},
+		{name: "blog--post", wantError: "invalid resource name"},
+		{name: "blog-", wantError: "invalid resource name"},
+		{name: "blog post", wantError: "invalid resource name"},
+		{name: "session", wantError: "reserved"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := newResourceData(tt.name, []string{"title", "published-at:time"})
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data.Table != tt.wantTable || data.URLPath != tt.wantPath || data.GoName != tt.wantGo {
+				t.Errorf("Table, URLPath, GoName = %q, %q, %q, want %q, %q, %q",
+					data.Table, data.URLPath, data.GoName, tt.wantTable, tt.wantPath, tt.wantGo)
+			}
+			if f := data.Fields[1]; f.Column != "published_at" || f.GoName != "PublishedAt" {
+				t.Errorf("field = %q, %q, want published_at, PublishedAt", f.Column, f.GoName)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reloadPath is the server-sent events endpoint the injected script listens on
+const reloadPath = "/_beesting/reload"
+
+// reloadScript is injected into every HTML page served through the proxy
+const reloadScript = `<script>(function () {
+  var source = new EventSource("` + reloadPath + `");
+  source.addEventListener("reload", function () { location.reload(); });
+})();</script>`
+
+// waitingPage is served while the app is not reachable, e.g. during a restart
+const waitingPage = `<!doctype html><html><head><title>Waiting for app...</title></head>
+<body style="font-family: sans-serif; padding: 2rem">
+<p>🐝 Waiting for the app to start...</p>
+</body></html>`
+
+// liveReloadProxy forwards requests to the app and tells browsers to reload
+// after a rebuild
+type liveReloadProxy struct {
+	appAddr string
+	proxy   *httputil.ReverseProxy
+
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+// newLiveReloadProxy creates a proxy in front of the app listening on appAddr
+func newLiveReloadProxy(appAddr string) *liveReloadProxy {
+	target := &url.URL{Scheme: "http", Host: appAddr}
+
+	p := &liveReloadProxy{
+		appAddr: appAddr,
+		clients: map[chan struct{}]struct{}{},
+	}
+
+	// Rewrite keeps the incoming Host header, so the app sees the proxy's
+	// host. It drops Accept-Encoding so pages arrive uncompressed and
+	// injectReloadScript can add the script.
+	p.proxy = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.Out.Host = r.In.Host
+			r.Out.Header.Del("Accept-Encoding")
+			r.SetXForwarded()
+		},
+		ModifyResponse: injectReloadScript,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusBadGateway)
+			io.WriteString(w, strings.Replace(waitingPage, "</body>", reloadScript+"</body>", 1))
+		},
+	}
+
+	return p
+}
+
+func (p *liveReloadProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == reloadPath {
+		p.serveEvents(w, r)
+		return
+	}
+	p.proxy.ServeHTTP(w, r)
+}
+
+// serveEvents streams reload events to a browser until it disconnects
+func (p *liveReloadProxy) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	io.WriteString(w, "retry: 500\n\n")
+	flusher.Flush()
+
+	reload := make(chan struct{}, 1)
+	p.mu.Lock()
+	p.clients[reload] = struct{}{}
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.clients, reload)
+		p.mu.Unlock()
+	}()
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			io.WriteString(w, ": ping\n\n")
+			flusher.Flush()
+		case <-reload:
+			io.WriteString(w, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// Reload tells every connected browser to reload once the app accepts
+// connections again
+func (p *liveReloadProxy) Reload() {
+	p.waitForApp(10 * time.Second)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for client := range p.clients {
+		select {
+		case client <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// waitForApp blocks until the app's port accepts connections or timeout passes
+func (p *liveReloadProxy) waitForApp(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", p.appAddr, 200*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// ListenAndServe serves the proxy on addr until ctx is done
+func (p *liveReloadProxy) ListenAndServe(ctx context.Context, addr string) error {
+	server := &http.Server{Addr: addr, Handler: p}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("failed to start live reload proxy: %w", err)
+	}
+	return nil
+}
+
+// injectReloadScript adds the reload script to uncompressed HTML responses
+func injectReloadScript(resp *http.Response) error {
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return nil
+	}
+	if resp.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	if i := bytes.LastIndex(body, []byte("</body>")); i >= 0 {
+		body = append(body[:i:i], append([]byte(reloadScript), body[i:]...)...)
+	} else {
+		body = append(body, reloadScript...)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
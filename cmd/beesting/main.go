@@ -23,4 +23,10 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(newCmd)
 	rootCmd.AddCommand(devCmd)
+	rootCmd.AddCommand(generateCmd)
+	rootCmd.AddCommand(dbCmd)
+	rootCmd.AddCommand(buildCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(routesCmd)
+	rootCmd.AddCommand(taskCmd)
 }
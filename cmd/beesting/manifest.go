@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// manifestFile is the name of the template manifest inside a template directory
+const manifestFile = "beesting.template.yaml"
+
+// templateManifest describes how a template is turned into a new app
+type templateManifest struct {
+	Name        string             `yaml:"name"`
+	Description string             `yaml:"description"`
+	Variables   []templateVariable `yaml:"variables"`
+	// Render lists glob patterns of files whose contents are rendered with
+	// text/template. Files ending in .tmpl are always rendered.
+	Render []string       `yaml:"render"`
+	Hooks  []templateHook `yaml:"hooks"`
+}
+
+// templateVariable is a value the user is asked for when creating an app
+type templateVariable struct {
+	Name   string `yaml:"name"`
+	Prompt string `yaml:"prompt"`
+	// Default is itself a template and may refer to earlier variables
+	Default string `yaml:"default"`
+}
+
+// templateHook is a command run in the new app directory after it was created
+type templateHook struct {
+	Name string `yaml:"name"`
+	Run  string `yaml:"run"`
+	// Requires names a binary the hook needs; the hook is skipped if it is missing
+	Requires string `yaml:"requires"`
+}
+
+// loadManifest reads the manifest from a template directory. Templates
+// without a manifest get an empty one.
+func loadManifest(dir string) (*templateManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if os.IsNotExist(err) {
+		return &templateManifest{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &templateManifest{}
+	if err := yaml.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", manifestFile, err)
+	}
+
+	for _, v := range manifest.Variables {
+		if v.Name == "" {
+			return nil, fmt.Errorf("invalid %s: variable without a name", manifestFile)
+		}
+	}
+
+	return manifest, nil
+}
+
+// resolveVariables fills data with the manifest's variables, taking values
+// from overrides first, then asking interactively, then using defaults
+func (m *templateManifest) resolveVariables(data map[string]any, overrides map[string]string, interactive bool) error {
+	var reader *bufio.Reader
+	if interactive {
+		reader = bufio.NewReader(os.Stdin)
+	}
+
+	for _, v := range m.Variables {
+		if value, ok := overrides[v.Name]; ok {
+			data[v.Name] = value
+			continue
+		}
+
+		def, err := renderString(v.Name, v.Default, data)
+		if err != nil {
+			return fmt.Errorf("default for %s: %w", v.Name, err)
+		}
+
+		value := def
+		if reader != nil {
+			prompt := v.Prompt
+			if prompt == "" {
+				prompt = v.Name
+			}
+			fmt.Printf("? %s [%s]: ", prompt, def)
+
+			answer, err := reader.ReadString('\n')
+			if err != nil && answer == "" {
+				return fmt.Errorf("failed to read %s: %w", v.Name, err)
+			}
+			if answer = strings.TrimSpace(answer); answer != "" {
+				value = answer
+			}
+		}
+
+		data[v.Name] = value
+	}
+
+	for name := range overrides {
+		if _, ok := data[name]; !ok {
+			return fmt.Errorf("unknown template variable '%s'", name)
+		}
+	}
+
+	return nil
+}
+
+// render applies the template data to file names, *.tmpl files and the
+// files matched by the manifest's render patterns inside dir
+func (m *templateManifest) render(dir string, data map[string]any) error {
+	var paths []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Render contents first, then rename deepest paths first so parents
+	// are still in place while their children move
+	for _, p := range paths {
+		info, err := os.Lstat(p)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		rel, _ := filepath.Rel(dir, p)
+		if !strings.HasSuffix(p, ".tmpl") && !m.shouldRender(filepath.ToSlash(rel)) {
+			continue
+		}
+
+		// Rendered keys are readable by the owner only, as in ensureSecretsFile
+		perm := info.Mode().Perm()
+		if strings.TrimSuffix(filepath.Base(p), ".tmpl") == secretsFile {
+			perm = 0600
+		}
+		if err := renderFile(p, perm, data); err != nil {
+			return err
+		}
+	}
+
+	for i := len(paths) - 1; i >= 0; i-- {
+		p := paths[i]
+		name := filepath.Base(p)
+		newName := strings.TrimSuffix(name, ".tmpl")
+
+		if strings.Contains(newName, "{{") {
+			rendered, err := renderString(name, newName, data)
+			if err != nil {
+				return err
+			}
+			newName = rendered
+		}
+
+		if newName == name {
+			continue
+		}
+		if newName == "" || strings.ContainsAny(newName, `/\`) || newName == ".." {
+			return fmt.Errorf("%s renders to invalid file name '%s'", name, newName)
+		}
+
+		if err := os.Rename(p, filepath.Join(filepath.Dir(p), newName)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// shouldRender reports whether a slash-separated path matches a render pattern
+func (m *templateManifest) shouldRender(rel string) bool {
+	for _, pattern := range m.Render {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		// Patterns without a directory match files at any depth
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runHooks runs the manifest's post-create hooks in appDir. Failing hooks
+// are reported but don't undo the app.
+func (m *templateManifest) runHooks(appDir string, data map[string]any) {
+	for _, hook := range m.Hooks {
+		command, err := renderString(hook.Name, hook.Run, data)
+		if err != nil {
+			fmt.Printf("⚠️  Skipping hook '%s': %v\n", hook.Name, err)
+			continue
+		}
+
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			continue
+		}
+
+		label := hook.Name
+		if label == "" {
+			label = command
+		}
+
+		required := hook.Requires
+		if required == "" {
+			required = args[0]
+		}
+		if _, err := exec.LookPath(required); err != nil {
+			fmt.Printf("⚠️  Skipping '%s': %s not found in PATH\n", label, required)
+			continue
+		}
+
+		fmt.Printf("🔧 %s\n", label)
+
+		hookCmd := exec.Command(args[0], args[1:]...)
+		hookCmd.Dir = appDir
+		hookCmd.Stdout = os.Stdout
+		hookCmd.Stderr = os.Stderr
+
+		if err := hookCmd.Run(); err != nil {
+			fmt.Printf("⚠️  '%s' failed: %v\n", command, err)
+		}
+	}
+}
+
+// renderFile renders a file's contents in place
+func renderFile(p string, perm fs.FileMode, data map[string]any) error {
+	content, err := os.ReadFile(p)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := renderString(filepath.Base(p), string(content), data)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(p, []byte(rendered), perm); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(p, perm)
+}
+
+// templateFuncs are available in every rendered template
+var templateFuncs = template.FuncMap{
+	"randomKey": randomKey,
+}
+
+// renderString executes text as a template. Missing keys are an error so
+// typos in templates don't silently render as "<no value>".
+func renderString(name, text string, data map[string]any) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return buf.String(), nil
+}
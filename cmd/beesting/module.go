@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// moduleRewrite describes how to move a template's Go code to a new module path
+type moduleRewrite struct {
+	OldModule  string
+	NewModule  string
+	Standalone bool
+}
+
+// planModuleRewrite works out the module path the template was written
+// against and the module path the new app should use.
+//
+// modulePath overrides the new path. Otherwise apps created inside a Go
+// module become a package of it (<module>/app/<name>); apps created outside
+// one get a standalone go.mod named after the project.
+func planModuleRewrite(appDir, templateName, projectName, modulePath string, standalone bool) (moduleRewrite, error) {
+	rw := moduleRewrite{
+		OldModule:  detectTemplateModule(appDir, templateName),
+		NewModule:  modulePath,
+		Standalone: standalone,
+	}
+
+	// Templates that ship their own go.mod are always standalone
+	if _, err := os.Stat(filepath.Join(appDir, "go.mod")); err == nil {
+		rw.Standalone = true
+	}
+
+	// Outside a Go module the app can only build with its own go.mod
+	rootModule := readModulePath("go.mod")
+	if rootModule == "" {
+		rw.Standalone = true
+	}
+
+	if rw.NewModule == "" {
+		if rw.Standalone {
+			rw.NewModule = projectName
+		} else {
+			rw.NewModule = path.Join(rootModule, "app", projectName)
+		}
+	}
+
+	if err := module.CheckImportPath(rw.NewModule); err != nil {
+		return rw, fmt.Errorf("invalid module path '%s': %w", rw.NewModule, err)
+	}
+
+	return rw, nil
+}
+
+// detectTemplateModule returns the import path prefix the template's own
+// packages are imported under
+func detectTemplateModule(appDir, templateName string) string {
+	if modulePath := readModulePath(filepath.Join(appDir, "go.mod")); modulePath != "" {
+		return modulePath
+	}
+
+	// Find imports that end in one of the template's own package directories
+	// and use the most common prefix
+	votes := map[string]int{}
+	filepath.WalkDir(appDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == "node_modules" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), p, nil, parser.ImportsOnly)
+		if err != nil {
+			return nil
+		}
+
+		for _, spec := range file.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			if prefix, ok := matchPackageDir(appDir, importPath); ok {
+				votes[prefix]++
+			}
+		}
+		return nil
+	})
+
+	detected, best := "", 0
+	for prefix, count := range votes {
+		if count > best || (count == best && prefix < detected) {
+			detected, best = prefix, count
+		}
+	}
+
+	if detected != "" {
+		return detected
+	}
+	return fmt.Sprintf("github.com/%s/app/%s", githubRepo, templateName)
+}
+
+// matchPackageDir checks whether importPath is <prefix>/<dir> for a
+// directory dir inside appDir and returns the prefix
+func matchPackageDir(appDir, importPath string) (string, bool) {
+	parts := strings.Split(importPath, "/")
+	for i := 1; i < len(parts); i++ {
+		rel := filepath.Join(parts[i:]...)
+		info, err := os.Stat(filepath.Join(appDir, rel))
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		// Require at least one .go file so unrelated directories don't match
+		if matches, _ := filepath.Glob(filepath.Join(appDir, rel, "*.go")); len(matches) > 0 {
+			return strings.Join(parts[:i], "/"), true
+		}
+	}
+	return "", false
+}
+
+// enclosingModule returns the directory of the go.mod that dir belongs to,
+// walking up from dir, or "" if dir isn't inside a Go module
+func enclosingModule(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// readModulePath returns the module path declared in a go.mod file
+func readModulePath(goModPath string) string {
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return ""
+	}
+	return modfile.ModulePath(data)
+}
+
+// rewriteModule moves the app in appDir from rw.OldModule to rw.NewModule
+func rewriteModule(appDir, projectName string, rw moduleRewrite) error {
+	if rw.OldModule != rw.NewModule {
+		if err := rewriteImportPaths(appDir, rw.OldModule, rw.NewModule); err != nil {
+			return fmt.Errorf("failed to rewrite import paths: %w", err)
+		}
+	}
+
+	if err := rewritePackageName(appDir, projectName); err != nil {
+		return fmt.Errorf("failed to rewrite package.json: %w", err)
+	}
+
+	if rw.Standalone {
+		if err := writeGoMod(appDir, rw.NewModule); err != nil {
+			return fmt.Errorf("failed to write go.mod: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// rewriteImportPaths replaces imports of oldModule in Go, templ and sqlc
+// files with newModule
+func rewriteImportPaths(appDir, oldModule, newModule string) error {
+	replacer := strings.NewReplacer(
+		`"`+oldModule+`"`, `"`+newModule+`"`,
+		`"`+oldModule+`/`, `"`+newModule+`/`,
+	)
+
+	return filepath.WalkDir(appDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == "node_modules" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		name := d.Name()
+		isSqlcConfig := name == "sqlc.yaml" || name == "sqlc.yml" || name == "sqlc.json"
+		if !strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, ".templ") && !isSqlcConfig {
+			return nil
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		var updated []byte
+		if isSqlcConfig {
+			// Overrides reference Go types by their full import path, unquoted
+			updated = bytes.ReplaceAll(content, []byte(oldModule+"/"), []byte(newModule+"/"))
+		} else {
+			updated = []byte(replacer.Replace(string(content)))
+		}
+
+		if bytes.Equal(content, updated) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(p, updated, info.Mode().Perm())
+	})
+}
+
+var packageNamePattern = regexp.MustCompile(`"name":\s*"([^"]*)"`)
+
+// rewritePackageName renames the npm package in package.json and package-lock.json
+func rewritePackageName(appDir, projectName string) error {
+	packageJSON := filepath.Join(appDir, "package.json")
+	content, err := os.ReadFile(packageJSON)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	match := packageNamePattern.FindSubmatch(content)
+	if match == nil {
+		return nil
+	}
+
+	oldName := string(match[1])
+	newName := npmPackageName(projectName)
+	if oldName == newName {
+		return nil
+	}
+
+	// Only the root package carries the old name in either file
+	for _, file := range []string{packageJSON, filepath.Join(appDir, "package-lock.json")} {
+		content, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		updated := packageNamePattern.ReplaceAllFunc(content, func(m []byte) []byte {
+			if string(packageNamePattern.FindSubmatch(m)[1]) != oldName {
+				return m
+			}
+			return []byte(`"name": "` + newName + `"`)
+		})
+
+		if err := os.WriteFile(file, updated, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// npmPackageName turns a project name into a valid npm package name
+func npmPackageName(name string) string {
+	name = strings.ToLower(name)
+	name = regexp.MustCompile(`[^a-z0-9._-]+`).ReplaceAllString(name, "-")
+	return strings.TrimLeft(name, "._-")
+}
+
+// writeGoMod gives the app its own go.mod and tries to resolve its dependencies
+func writeGoMod(appDir, modulePath string) error {
+	goModPath := filepath.Join(appDir, "go.mod")
+
+	data, err := os.ReadFile(goModPath)
+	if err == nil {
+		// Keep the template's requirements and only rename the module
+		f, err := modfile.Parse(goModPath, data, nil)
+		if err != nil {
+			return err
+		}
+		if err := f.AddModuleStmt(modulePath); err != nil {
+			return err
+		}
+		data, err = f.Format()
+		if err != nil {
+			return err
+		}
+	} else {
+		data = fmt.Appendf(nil, "module %s\n\ngo %s\n", modulePath, goVersion())
+	}
+
+	if err := os.WriteFile(goModPath, data, 0644); err != nil {
+		return err
+	}
+
+	if _, err := exec.LookPath("go"); err != nil {
+		fmt.Println("⚠️  Go not found in PATH; run 'go mod tidy' in the app directory")
+		return nil
+	}
+
+	tidyCmd := exec.Command("go", "mod", "tidy")
+	tidyCmd.Dir = appDir
+	if output, err := tidyCmd.CombinedOutput(); err != nil {
+		fmt.Printf("⚠️  'go mod tidy' failed, run it manually in %s:\n%s\n", appDir, output)
+	}
+
+	return nil
+}
+
+// goVersion returns the go directive for new modules, preferring the
+// version used by the surrounding module
+func goVersion() string {
+	if data, err := os.ReadFile("go.mod"); err == nil {
+		if f, err := modfile.ParseLax("go.mod", data, nil); err == nil && f.Go != nil {
+			return f.Go.Version
+		}
+	}
+
+	version := strings.TrimPrefix(runtime.Version(), "go")
+	if parts := strings.SplitN(version, ".", 3); len(parts) >= 2 {
+		return parts[0] + "." + parts[1]
+	}
+	return version
+}
+
+// appModulePath returns the import path of an existing app's root package
+func appModulePath(appDir string) string {
+	if modulePath := readModulePath(filepath.Join(appDir, "go.mod")); modulePath != "" {
+		return modulePath
+	}
+	return path.Join(readModulePath("go.mod"), filepath.ToSlash(filepath.Clean(appDir)))
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnclosingModule(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"repo/app/blog/views", "repo/app/shop", "loose/app"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, goMod := range []string{"repo/go.mod", "repo/app/shop/go.mod"} {
+		if err := os.WriteFile(filepath.Join(root, goMod), []byte("module example.com/x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"repo", "repo"},
+		{"repo/app/blog", "repo"},
+		{"repo/app/blog/views", "repo"},
+		{"repo/app/shop", "repo/app/shop"},
+		{"loose/app", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := enclosingModule(filepath.Join(root, tt.dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := tt.want
+		if want != "" {
+			want = filepath.Join(root, want)
+		}
+		if got != want {
+			t.Errorf("enclosingModule(%s) = %q, want %q", tt.dir, got, want)
+		}
+	}
+}
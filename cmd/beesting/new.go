@@ -5,10 +5,13 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
-	"net/http"
+	"io/fs"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 )
@@ -16,6 +19,19 @@ import (
 const (
 	githubRepo   = "nick-friedrich/beesting"
 	githubBranch = "main"
+
+	// defaultTemplate is the embedded template used when none is given
+	defaultTemplate = "default"
+)
+
+var (
+	newFrom       string
+	newSource     string
+	newModule     string
+	newStandalone bool
+	newVars       []string
+	newYes        bool
+	newNoHooks    bool
 )
 
 // newCmd creates a new application
@@ -25,108 +41,204 @@ var newCmd = &cobra.Command{
 	Long: `Create a new application in the app directory.
 
 Usage:
-  beesting new <name>                 - Create with default template
-  beesting new <template> <name>      - Create from GitHub template`,
+  beesting new <name>                       - Create with default template
+  beesting new <template> <name>            - Create from an embedded or GitHub template
+  beesting new <name> --from <path>         - Create from a local template directory or .tar.gz
+  beesting new <template> <name> --from <p> - Create from app/<template> in a local checkout or archive
+
+Templates are looked up in the templates embedded in the beesting binary
+first and downloaded from GitHub otherwise. Use --source to force one.
+
+A template may include a beesting.template.yaml manifest declaring variables
+(prompted for, or set with --var key=value) that are rendered into *.tmpl
+files and file names, and hooks run in the new app afterwards (skip them
+with --no-hooks).`,
 	Args: cobra.RangeArgs(1, 2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var templateName, projectName string
 
 		if len(args) == 1 {
-			// beesting new my-project (use default template)
+			// beesting new my-project (use default template, or the --from template as-is)
 			projectName = args[0]
-			return createFromDefaultTemplate(projectName)
+			if newFrom == "" {
+				templateName = defaultTemplate
+			}
+		} else {
+			// beesting new example-app my-project
+			templateName = args[0]
+			projectName = args[1]
+		}
+
+		source, err := resolveTemplateSource(templateName, newFrom, newSource)
+		if err != nil {
+			return err
 		}
 
-		// beesting new example-app my-project (fetch from GitHub)
-		templateName = args[0]
-		projectName = args[1]
-		return createFromGitHubTemplate(templateName, projectName)
+		return createFromTemplate(source, templateName, projectName)
 	},
 }
 
-// createFromDefaultTemplate creates a project with the built-in template
-func createFromDefaultTemplate(name string) error {
-	// Create app directory
-	appDir := filepath.Join("app", name)
-	if err := os.MkdirAll(appDir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+func init() {
+	newCmd.Flags().StringVar(&newFrom, "from", "", "local template directory or .tar.gz archive")
+	newCmd.Flags().StringVar(&newSource, "source", "auto", "template source: auto, embedded, github or local")
+	newCmd.Flags().StringVar(&newModule, "module", "", "Go module path for the new app (default <root module>/app/<name>)")
+	newCmd.Flags().BoolVar(&newStandalone, "go-mod", false, "give the new app its own go.mod")
+	newCmd.Flags().StringArrayVar(&newVars, "var", nil, "set a template variable (key=value), can be repeated")
+	newCmd.Flags().BoolVarP(&newYes, "yes", "y", false, "use defaults for template variables instead of prompting")
+	newCmd.Flags().BoolVar(&newNoHooks, "no-hooks", false, "don't run the template's post-create hooks")
+}
+
+// createFromTemplate copies a template from source into app/<projectName>
+func createFromTemplate(source templateSource, templateName, projectName string) error {
+	appDir := filepath.Join("app", projectName)
+
+	// Check if directory already exists
+	if _, err := os.Stat(appDir); err == nil {
+		return fmt.Errorf("app '%s' already exists", projectName)
+	}
+
+	label := templateName
+	if label == "" {
+		label = strings.TrimSuffix(strings.TrimSuffix(filepath.Base(newFrom), ".tar.gz"), ".tgz")
 	}
 
-	// Create main.go
-	mainGoPath := filepath.Join(appDir, "main.go")
+	if label != defaultTemplate {
+		fmt.Printf("📥 Fetching template '%s' from %s...\n", label, source.Describe())
+	}
 
-	mainGoContent := fmt.Sprintf(`package main
+	// Build the app in a staging directory next to its final location and only
+	// move it into place once every step succeeded
+	stagingDir, cleanup, err := newStagingDir(filepath.Dir(appDir))
+	if err != nil {
+		return err
+	}
+	defer cleanup()
 
-import (
-	"net/http"
+	if err := source.Extract(templateName, stagingDir); err != nil {
+		return fmt.Errorf("failed to extract template: %w", err)
+	}
 
-	"github.com/nick-friedrich/beesting/pkg/beesting"
-)
+	manifest, err := loadManifest(stagingDir)
+	if err != nil {
+		return err
+	}
+	os.Remove(filepath.Join(stagingDir, manifestFile))
 
-func main() {
-	app := beesting.NewApp()
+	rw, err := planModuleRewrite(stagingDir, templateName, projectName, newModule, newStandalone)
+	if err != nil {
+		return err
+	}
+
+	overrides, err := parseVars(newVars)
+	if err != nil {
+		return err
+	}
 
-	// Add middleware
-	app.Use(beesting.Logger())
-	app.Use(beesting.Recovery())
+	data := map[string]any{
+		"Name":     projectName,
+		"Module":   rw.NewModule,
+		"Template": label,
+	}
+	if err := manifest.resolveVariables(data, overrides, !newYes && isTerminal(os.Stdin)); err != nil {
+		return err
+	}
 
-	// Routes
-	app.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("Hello from %s!"))
-	})
+	if err := manifest.render(stagingDir, data); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
 
-	app.Run(":8080")
-}
-`, name)
+	if err := rewriteModule(stagingDir, projectName, rw); err != nil {
+		return err
+	}
 
-	if err := os.WriteFile(mainGoPath, []byte(mainGoContent), 0644); err != nil {
-		return fmt.Errorf("failed to create main.go: %w", err)
+	if err := os.Rename(stagingDir, appDir); err != nil {
+		return fmt.Errorf("failed to move app into place: %w", err)
 	}
 
-	fmt.Printf("✓ Created new app: %s\n", name)
+	if label == defaultTemplate {
+		fmt.Printf("✓ Created new app: %s\n", projectName)
+	} else {
+		fmt.Printf("✓ Created new app: %s (from template: %s)\n", projectName, label)
+	}
 	fmt.Printf("  Location: %s\n", appDir)
-	fmt.Printf("\nRun with: beesting dev %s\n", name)
+	fmt.Printf("  Module:   %s\n", rw.NewModule)
+
+	if !newNoHooks && len(manifest.Hooks) > 0 {
+		fmt.Println()
+		manifest.runHooks(appDir, data)
+	}
+
+	fmt.Printf("\nRun with: beesting dev %s\n", projectName)
 
 	return nil
 }
 
-// createFromGitHubTemplate fetches a template from GitHub and creates a project
-func createFromGitHubTemplate(templateName, projectName string) error {
-	appDir := filepath.Join("app", projectName)
-
-	// Check if directory already exists
-	if _, err := os.Stat(appDir); err == nil {
-		return fmt.Errorf("app '%s' already exists", projectName)
+// parseVars turns key=value flags into a map
+func parseVars(vars []string) (map[string]string, error) {
+	values := make(map[string]string, len(vars))
+	for _, v := range vars {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var '%s', expected key=value", v)
+		}
+		values[key] = value
 	}
+	return values, nil
+}
 
-	fmt.Printf("📥 Fetching template '%s' from GitHub...\n", templateName)
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
 
-	// Download the tarball from GitHub
-	url := fmt.Sprintf("https://github.com/%s/archive/refs/heads/%s.tar.gz", githubRepo, githubBranch)
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to download template: %w", err)
+// newStagingDir creates a hidden temporary directory inside parent for
+// building a new app. The returned cleanup function removes it unless it has
+// been renamed away, and also runs if the command is interrupted.
+func newStagingDir(parent string) (string, func(), error) {
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", nil, fmt.Errorf("failed to create directory: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download template: HTTP %d", resp.StatusCode)
+	dir, err := os.MkdirTemp(parent, ".beesting-new-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create staging directory: %w", err)
 	}
 
-	// Extract the specific template folder
-	if err := extractTemplate(resp.Body, templateName, appDir); err != nil {
-		return fmt.Errorf("failed to extract template: %w", err)
+	// MkdirTemp creates the directory private to the current user
+	if err := os.Chmod(dir, 0755); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
 	}
 
-	fmt.Printf("✓ Created new app: %s (from template: %s)\n", projectName, templateName)
-	fmt.Printf("  Location: %s\n", appDir)
-	fmt.Printf("\nRun with: beesting dev %s\n", projectName)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
 
-	return nil
+	go func() {
+		select {
+		case <-signals:
+			os.RemoveAll(dir)
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+
+	cleanup := func() {
+		signal.Stop(signals)
+		close(done)
+		os.RemoveAll(dir)
+	}
+
+	return dir, cleanup, nil
 }
 
-// extractTemplate extracts a specific folder from a tar.gz archive
-func extractTemplate(r io.Reader, templateName, destDir string) error {
+// extractTemplate extracts the files below templatePath from a tar.gz archive.
+//
+// Entries must stay inside destDir: absolute paths, ".." components, writes
+// through previously extracted symlinks and links pointing outside destDir
+// are rejected.
+func extractTemplate(r io.Reader, templatePath, destDir string) error {
 	gzr, err := gzip.NewReader(r)
 	if err != nil {
 		return err
@@ -134,7 +246,6 @@ func extractTemplate(r io.Reader, templateName, destDir string) error {
 	defer gzr.Close()
 
 	tr := tar.NewReader(gzr)
-	templatePath := fmt.Sprintf("beesting-%s/app/%s/", githubBranch, templateName)
 	found := false
 
 	for {
@@ -146,20 +257,25 @@ func extractTemplate(r io.Reader, templateName, destDir string) error {
 			return err
 		}
 
+		name := strings.TrimPrefix(header.Name, "./")
+
 		// Check if this file is in our template folder
-		if !strings.HasPrefix(header.Name, templatePath) {
+		if !strings.HasPrefix(name, templatePath) {
 			continue
 		}
 
 		found = true
 
 		// Get relative path within template
-		relPath := strings.TrimPrefix(header.Name, templatePath)
-		if relPath == "" {
+		relPath := strings.TrimSuffix(strings.TrimPrefix(name, templatePath), "/")
+		if relPath == "" || skipTemplateEntry(relPath) {
 			continue
 		}
 
-		target := filepath.Join(destDir, relPath)
+		target, err := safeTarget(destDir, relPath)
+		if err != nil {
+			return err
+		}
 
 		switch header.Typeflag {
 		case tar.TypeDir:
@@ -167,28 +283,120 @@ func extractTemplate(r io.Reader, templateName, destDir string) error {
 				return err
 			}
 		case tar.TypeReg:
-			// Create parent directory
 			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
 				return err
 			}
-
-			// Create file
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, os.FileMode(header.Mode))
+			if err := writeFile(target, tr, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := createSymlink(destDir, relPath, header.Linkname); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			// Hard links name another entry of the same archive
+			linkName := strings.TrimPrefix(header.Linkname, "./")
+			if !strings.HasPrefix(linkName, templatePath) {
+				return fmt.Errorf("hard link %s points outside the template", relPath)
+			}
+			source, err := safeTarget(destDir, strings.TrimPrefix(linkName, templatePath))
 			if err != nil {
 				return err
 			}
-
-			if _, err := io.Copy(f, tr); err != nil {
-				f.Close()
+			// Linking a symlink copies it, and its relative target would
+			// then resolve from a different directory
+			if info, err := os.Lstat(source); err != nil || !info.Mode().IsRegular() {
+				return fmt.Errorf("hard link %s must point to a file extracted before it", relPath)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Link(source, target); err != nil {
 				return err
 			}
-			f.Close()
 		}
 	}
 
 	if !found {
-		return fmt.Errorf("template '%s' not found in repository", templateName)
+		return fmt.Errorf("'%s' not found in archive", strings.TrimSuffix(templatePath, "/"))
 	}
 
 	return nil
 }
+
+// safeTarget resolves a slash-separated template path inside destDir,
+// refusing paths that escape it or that traverse a symlink
+func safeTarget(destDir, relPath string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(relPath)) {
+		return "", fmt.Errorf("illegal path in template: %s", relPath)
+	}
+
+	// Every parent that already exists must be a real directory, otherwise
+	// a symlink extracted earlier could redirect the write outside destDir
+	current := destDir
+	parts := strings.Split(relPath, "/")
+	for _, part := range parts[:len(parts)-1] {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("illegal path in template: %s traverses a symlink", relPath)
+		}
+	}
+
+	return filepath.Join(destDir, filepath.FromSlash(relPath)), nil
+}
+
+// createSymlink creates relPath inside destDir pointing at linkname, which
+// must be relative and resolve inside destDir
+func createSymlink(destDir, relPath, linkname string) error {
+	target, err := safeTarget(destDir, relPath)
+	if err != nil {
+		return err
+	}
+
+	if filepath.IsAbs(linkname) {
+		return fmt.Errorf("symlink %s points to absolute path %s", relPath, linkname)
+	}
+	// ".." may only lead the target, so it climbs the real directories above
+	// the link. After another component it could climb out of a directory
+	// reached through a symlink, which the check below can't see.
+	parts := strings.Split(filepath.ToSlash(linkname), "/")
+	i := 0
+	for i < len(parts) && parts[i] == ".." {
+		i++
+	}
+	if slices.Contains(parts[i:], "..") {
+		return fmt.Errorf("symlink %s may only use .. at the start of its target %s", relPath, linkname)
+	}
+	resolved := filepath.Join(filepath.Dir(target), filepath.FromSlash(linkname))
+	if rel, err := filepath.Rel(destDir, resolved); err != nil || !filepath.IsLocal(rel) {
+		return fmt.Errorf("symlink %s points outside the template", relPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	os.Remove(target)
+	return os.Symlink(linkname, target)
+}
+
+// writeFile writes r to path, replacing any existing file
+func writeFile(path string, r io.Reader, perm fs.FileMode) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm|0200)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
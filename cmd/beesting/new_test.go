@@ -0,0 +1,304 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tarEntry is an archive entry for buildArchive; body is the file content
+// or, for links, the link target
+type tarEntry struct {
+	name string
+	typ  byte
+	body string
+}
+
+func buildArchive(t *testing.T, entries []tarEntry) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Typeflag: e.typ, Mode: 0644}
+		switch e.typ {
+		case tar.TypeReg:
+			header.Size = int64(len(e.body))
+		case tar.TypeDir:
+			header.Mode = 0755
+		case tar.TypeSymlink, tar.TypeLink:
+			header.Linkname = e.body
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if e.typ == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func file(name, body string) tarEntry   { return tarEntry{name, tar.TypeReg, body} }
+func dir(name string) tarEntry          { return tarEntry{name, tar.TypeDir, ""} }
+func symlink(name, to string) tarEntry  { return tarEntry{name, tar.TypeSymlink, to} }
+func hardlink(name, to string) tarEntry { return tarEntry{name, tar.TypeLink, to} }
+
+func TestExtractTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		wantErr string
+		// want maps paths inside the app to their content, or "->target"
+		// for symlinks
+		want map[string]string
+	}{
+		{
+			name: "files and directories",
+			entries: []tarEntry{
+				dir("./tmpl/"),
+				file("./tmpl/main.go", "package main"),
+				file("tmpl/views/home.templ", "home"),
+				file("other/skip.go", "not in the template"),
+			},
+			want: map[string]string{"main.go": "package main", "views/home.templ": "home"},
+		},
+		{
+			name: "local state is skipped",
+			entries: []tarEntry{
+				file("tmpl/main.go", "package main"),
+				file("tmpl/node_modules/x.js", "x"),
+				file("tmpl/app.db", "db"),
+				file("tmpl/secrets.yaml", "key"),
+				file("tmpl/.env", "KEY=1"),
+			},
+			want: map[string]string{"main.go": "package main"},
+		},
+		{
+			name:    "template missing",
+			entries: []tarEntry{file("other/main.go", "package main")},
+			wantErr: "'tmpl' not found",
+		},
+		{
+			name:    "dot dot entry",
+			entries: []tarEntry{file("tmpl/../evil", "x")},
+			wantErr: "illegal path",
+		},
+		{
+			name:    "nested dot dot entry",
+			entries: []tarEntry{file("tmpl/a/../../evil", "x")},
+			wantErr: "illegal path",
+		},
+		{
+			name:    "absolute entry",
+			entries: []tarEntry{file("tmpl//etc/evil", "x")},
+			wantErr: "illegal path",
+		},
+		{
+			name: "symlink inside",
+			entries: []tarEntry{
+				file("tmpl/a/target.txt", "x"),
+				symlink("tmpl/b/link", "../a/target.txt"),
+			},
+			want: map[string]string{"a/target.txt": "x", "b/link": "->../a/target.txt"},
+		},
+		{
+			name:    "symlink to absolute path",
+			entries: []tarEntry{symlink("tmpl/link", "/etc/passwd")},
+			wantErr: "absolute path",
+		},
+		{
+			name:    "symlink escaping",
+			entries: []tarEntry{symlink("tmpl/a/link", "../../evil")},
+			wantErr: "points outside",
+		},
+		{
+			name: "symlink chain climbing out of a symlinked directory",
+			entries: []tarEntry{
+				symlink("tmpl/d/up", ".."),
+				symlink("tmpl/escape", "d/up/.."),
+			},
+			wantErr: "may only use ..",
+		},
+		{
+			name: "write through an extracted symlink",
+			entries: []tarEntry{
+				dir("tmpl/real/"),
+				symlink("tmpl/link", "real"),
+				file("tmpl/link/evil", "x"),
+			},
+			wantErr: "traverses a symlink",
+		},
+		{
+			name: "symlink through an extracted symlink",
+			entries: []tarEntry{
+				dir("tmpl/real/"),
+				symlink("tmpl/link", "real"),
+				symlink("tmpl/link/inner", "."),
+			},
+			wantErr: "traverses a symlink",
+		},
+		{
+			name: "hard link inside",
+			entries: []tarEntry{
+				file("tmpl/a.txt", "x"),
+				hardlink("tmpl/b.txt", "tmpl/a.txt"),
+			},
+			want: map[string]string{"a.txt": "x", "b.txt": "x"},
+		},
+		{
+			name: "hard link outside the template",
+			entries: []tarEntry{
+				file("other/secret", "x"),
+				file("tmpl/a.txt", "x"),
+				hardlink("tmpl/b.txt", "other/secret"),
+			},
+			wantErr: "outside the template",
+		},
+		{
+			name:    "hard link escaping",
+			entries: []tarEntry{hardlink("tmpl/b.txt", "tmpl/../../etc/passwd")},
+			wantErr: "illegal path",
+		},
+		{
+			name: "hard link to a symlink",
+			entries: []tarEntry{
+				symlink("tmpl/d/up", ".."),
+				hardlink("tmpl/escape", "tmpl/d/up"),
+			},
+			wantErr: "must point to a file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := t.TempDir()
+			destDir := filepath.Join(parent, "app")
+			if err := os.Mkdir(destDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := extractTemplate(buildArchive(t, tt.entries), "tmpl/", destDir)
+
+			// Nothing may ever land next to the app
+			if siblings, _ := os.ReadDir(parent); len(siblings) != 1 {
+				t.Errorf("extraction wrote outside the app: %v", siblings)
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := map[string]string{}
+			filepath.WalkDir(destDir, func(p string, d os.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				rel, _ := filepath.Rel(destDir, p)
+				if d.Type()&os.ModeSymlink != 0 {
+					target, _ := os.Readlink(p)
+					got[filepath.ToSlash(rel)] = "->" + target
+					return nil
+				}
+				content, _ := os.ReadFile(p)
+				got[filepath.ToSlash(rel)] = string(content)
+				return nil
+			})
+			if len(got) != len(tt.want) {
+				t.Fatalf("extracted %v, want %v", got, tt.want)
+			}
+			for p, want := range tt.want {
+				if got[p] != want {
+					t.Errorf("%s = %q, want %q", p, got[p], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSafeTarget(t *testing.T) {
+	destDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(destDir, "real"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("real", filepath.Join(destDir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		relPath string
+		ok      bool
+	}{
+		{"main.go", true},
+		{"real/main.go", true},
+		{"new/dir/main.go", true},
+		// The link itself may be replaced, but not written through
+		{"link", true},
+		{"link/main.go", false},
+		{"link/deeper/main.go", false},
+		{"../main.go", false},
+		{"a/../../main.go", false},
+		{"/etc/passwd", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		got, err := safeTarget(destDir, tt.relPath)
+		if tt.ok {
+			if err != nil {
+				t.Errorf("safeTarget(%q) failed: %v", tt.relPath, err)
+			} else if want := filepath.Join(destDir, filepath.FromSlash(tt.relPath)); got != want {
+				t.Errorf("safeTarget(%q) = %q, want %q", tt.relPath, got, want)
+			}
+		} else if err == nil {
+			t.Errorf("safeTarget(%q) = %q, want an error", tt.relPath, got)
+		}
+	}
+}
+
+func TestCreateSymlink(t *testing.T) {
+	tests := []struct {
+		relPath  string
+		linkname string
+		ok       bool
+	}{
+		{"link", "main.go", true},
+		{"a/link", "../main.go", true},
+		{"a/b/link", "../../c/d", true},
+		{"a/link", "./b/../c", false},
+		{"link", "..", false},
+		{"a/link", "../..", false},
+		{"link", "/etc/passwd", false},
+		{"../link", "main.go", false},
+	}
+
+	for _, tt := range tests {
+		destDir := t.TempDir()
+		err := createSymlink(destDir, tt.relPath, tt.linkname)
+		if tt.ok && err != nil {
+			t.Errorf("createSymlink(%q, %q) failed: %v", tt.relPath, tt.linkname, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("createSymlink(%q, %q) succeeded, want an error", tt.relPath, tt.linkname)
+		}
+	}
+}
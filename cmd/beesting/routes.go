@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nick-friedrich/beesting/pkg/beesting"
+	"github.com/spf13/cobra"
+)
+
+var routesJSON bool
+
+// routesCmd prints an app's route table
+var routesCmd = &cobra.Command{
+	Use:   "routes <app>",
+	Short: "List an application's routes",
+	Long: `List the routes an application registers, with their handlers and
+middleware, and warn about routes that don't behave the way they read.
+
+The app is started with BEESTING_COMMAND=routes, which makes
+beesting.App.Run print the routes instead of serving. Everything in main
+before Run still executes, with DB_FILE set to an in-memory database.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appDir, err := findAppDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		routes, err := loadRoutes(appDir)
+		if err != nil {
+			return err
+		}
+
+		if routesJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(routes)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "METHOD\tPATTERN\tHANDLER\tMIDDLEWARE")
+		for _, route := range routes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", route.Method, route.Pattern, route.Handler, strings.Join(route.Middlewares, ", "))
+		}
+		w.Flush()
+
+		if warnings := beesting.RouteWarnings(routes); len(warnings) > 0 {
+			fmt.Println()
+			for _, warning := range warnings {
+				fmt.Printf("⚠️  %s\n", warning)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	routesCmd.Flags().BoolVar(&routesJSON, "json", false, "print the routes as JSON")
+}
+
+// loadRoutes runs the app in routes mode and decodes the routes it prints
+func loadRoutes(appDir string) ([]beesting.Route, error) {
+	var stdout, stderr bytes.Buffer
+
+	runCmd := exec.Command("go", "run", "-tags", "dev", ".")
+	runCmd.Dir = appDir
+	runCmd.Env = append(appEnv(appDir), beesting.CommandEnv+"=routes", "DB_FILE=:memory:")
+	runCmd.Stdout = &stdout
+	runCmd.Stderr = &stderr
+
+	if err := runCmd.Run(); err != nil {
+		os.Stderr.Write(stderr.Bytes())
+		return nil, fmt.Errorf("failed to run %s: %w", appDir, err)
+	}
+
+	// The app may print other output; the routes are the JSON array line
+	scanner := bufio.NewScanner(&stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !bytes.HasPrefix(line, []byte("[")) {
+			continue
+		}
+
+		var routes []beesting.Route
+		if err := json.Unmarshal(line, &routes); err == nil {
+			return routes, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s did not print its routes; does main call beesting.App.Run?", appDir)
+}
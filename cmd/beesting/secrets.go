@@ -0,0 +1,50 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// secretsFile is the key file apps read their secrets from
+const secretsFile = "secrets.yaml"
+
+// randomKey returns 32 random bytes, hex encoded; templates use it as
+// {{ randomKey }} to generate keys for new apps
+func randomKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// ensureSecretsFile renders secrets.yaml from the app's secrets.yaml.tmpl
+// if it doesn't exist yet, so keys stay the same across restarts. It
+// reports whether it created the file.
+func ensureSecretsFile(appDir string) (bool, error) {
+	path := filepath.Join(appDir, secretsFile)
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	}
+
+	tmpl, err := os.ReadFile(path + ".tmpl")
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	rendered, err := renderString(secretsFile, string(tmpl), map[string]any{"Name": filepath.Base(appDir)})
+	if err != nil {
+		return false, err
+	}
+
+	if err := os.WriteFile(path, []byte(rendered), 0600); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return true, nil
+}
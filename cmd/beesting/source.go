@@ -0,0 +1,345 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// embeddedTemplates holds the templates compiled into the beesting binary.
+// Each template is either a directory or a <name>.tar.gz archive.
+//
+//go:embed all:templates
+var embeddedTemplates embed.FS
+
+// templateSource provides the files of an app template
+type templateSource interface {
+	// Describe returns a short human-readable name for progress output
+	Describe() string
+	// Extract writes the files of the named template into destDir
+	Extract(templateName, destDir string) error
+}
+
+// resolveTemplateSource picks where to load a template from.
+//
+// from is a local directory or tarball and always wins. Otherwise source
+// selects "embedded" or "github"; "auto" prefers templates embedded in the
+// binary and falls back to GitHub.
+func resolveTemplateSource(templateName, from, source string) (templateSource, error) {
+	if from != "" {
+		info, err := os.Stat(from)
+		if err != nil {
+			return nil, fmt.Errorf("template source '%s' not found: %w", from, err)
+		}
+		if info.IsDir() {
+			return &dirSource{path: from}, nil
+		}
+		return &tarballSource{path: from}, nil
+	}
+
+	switch source {
+	case "", "auto":
+		if hasEmbeddedTemplate(templateName) {
+			return &embeddedSource{}, nil
+		}
+		return &githubSource{repo: githubRepo, branch: githubBranch}, nil
+	case "embedded":
+		if !hasEmbeddedTemplate(templateName) {
+			return nil, fmt.Errorf("template '%s' is not embedded in this binary (available: %s)",
+				templateName, strings.Join(embeddedTemplateNames(), ", "))
+		}
+		return &embeddedSource{}, nil
+	case "github":
+		return &githubSource{repo: githubRepo, branch: githubBranch}, nil
+	case "local":
+		return nil, fmt.Errorf("--source local requires --from <path>")
+	default:
+		return nil, fmt.Errorf("unknown template source '%s' (expected auto, embedded, github or local)", source)
+	}
+}
+
+// githubSource downloads templates from the app directory of a GitHub repository
+type githubSource struct {
+	repo   string
+	branch string
+}
+
+func (s *githubSource) Describe() string {
+	return "GitHub"
+}
+
+func (s *githubSource) Extract(templateName, destDir string) error {
+	url := fmt.Sprintf("https://github.com/%s/archive/refs/heads/%s.tar.gz", s.repo, s.branch)
+	resp, err := http.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to download template: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download template: HTTP %d", resp.StatusCode)
+	}
+
+	// GitHub archives contain a single <repo>-<branch>/ top-level directory
+	repoName := path.Base(s.repo)
+	templatePath := fmt.Sprintf("%s-%s/app/%s/", repoName, s.branch, templateName)
+
+	return extractTemplate(resp.Body, templatePath, destDir)
+}
+
+// tarballSource reads templates from a local .tar.gz archive.
+//
+// When a template name is given the archive is searched for app/<template>/,
+// either at its root or below a single top-level directory (the layout of a
+// GitHub archive). Without a name the archive itself is the template, with a
+// shared top-level directory stripped.
+type tarballSource struct {
+	path string
+	open func() (io.ReadCloser, error)
+}
+
+func (s *tarballSource) Describe() string {
+	return "archive " + s.path
+}
+
+func (s *tarballSource) Extract(templateName, destDir string) error {
+	open := s.open
+	if open == nil {
+		open = func() (io.ReadCloser, error) { return os.Open(s.path) }
+	}
+
+	// First pass: find where the template lives inside the archive
+	f, err := open()
+	if err != nil {
+		return err
+	}
+	templatePath, err := findTemplatePath(f, templateName)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	// Second pass: extract it
+	f, err = open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return extractTemplate(f, templatePath, destDir)
+}
+
+// findTemplatePath returns the archive prefix under which the template's files live
+func findTemplatePath(r io.Reader, templateName string) (string, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return "", err
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	topLevel := ""
+	sharedTopLevel := true
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		name := strings.TrimPrefix(header.Name, "./")
+		if name == "" {
+			continue
+		}
+
+		if templateName != "" {
+			marker := "app/" + templateName + "/"
+			if strings.HasPrefix(name, marker) {
+				return marker, nil
+			}
+			if i := strings.Index(name, "/"); i > 0 && strings.HasPrefix(name[i+1:], marker) {
+				return name[:i+1] + marker, nil
+			}
+			continue
+		}
+
+		first, _, nested := strings.Cut(name, "/")
+		if !nested && header.Typeflag != tar.TypeDir {
+			sharedTopLevel = false
+		}
+		if topLevel == "" {
+			topLevel = first
+		} else if topLevel != first {
+			sharedTopLevel = false
+		}
+	}
+
+	if templateName != "" {
+		return "", fmt.Errorf("template '%s' not found in archive", templateName)
+	}
+
+	if sharedTopLevel && topLevel != "" {
+		return topLevel + "/", nil
+	}
+	return "", nil
+}
+
+// dirSource copies templates from a local directory.
+//
+// With a template name the directory is treated as a checkout containing
+// app/<template> (or <template>); without one the directory is the template.
+type dirSource struct {
+	path string
+}
+
+func (s *dirSource) Describe() string {
+	if abs, err := filepath.Abs(s.path); err == nil {
+		return "local directory " + abs
+	}
+	return "local directory " + s.path
+}
+
+func (s *dirSource) Extract(templateName, destDir string) error {
+	root := s.path
+	if templateName != "" {
+		root = filepath.Join(s.path, "app", templateName)
+		if _, err := os.Stat(root); err != nil {
+			root = filepath.Join(s.path, templateName)
+		}
+	}
+
+	info, err := os.Stat(root)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("template '%s' not found in %s", templateName, s.path)
+	}
+
+	return copyTemplateFS(os.DirFS(root), destDir)
+}
+
+// embeddedSource copies templates compiled into the beesting binary
+type embeddedSource struct{}
+
+func (s *embeddedSource) Describe() string {
+	return "embedded templates"
+}
+
+func (s *embeddedSource) Extract(templateName, destDir string) error {
+	if info, err := fs.Stat(embeddedTemplates, path.Join("templates", templateName)); err == nil && info.IsDir() {
+		sub, err := fs.Sub(embeddedTemplates, path.Join("templates", templateName))
+		if err != nil {
+			return err
+		}
+		return copyTemplateFS(sub, destDir)
+	}
+
+	archive := path.Join("templates", templateName+".tar.gz")
+	tarball := &tarballSource{
+		path: archive,
+		open: func() (io.ReadCloser, error) { return embeddedTemplates.Open(archive) },
+	}
+	return tarball.Extract("", destDir)
+}
+
+// hasEmbeddedTemplate reports whether a template is compiled into the binary
+func hasEmbeddedTemplate(templateName string) bool {
+	if _, err := fs.Stat(embeddedTemplates, path.Join("templates", templateName)); err == nil {
+		return true
+	}
+	_, err := fs.Stat(embeddedTemplates, path.Join("templates", templateName+".tar.gz"))
+	return err == nil
+}
+
+// embeddedTemplateNames lists the templates compiled into the binary
+func embeddedTemplateNames() []string {
+	entries, err := fs.ReadDir(embeddedTemplates, "templates")
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			names = append(names, name)
+		} else if strings.HasSuffix(name, ".tar.gz") {
+			names = append(names, strings.TrimSuffix(name, ".tar.gz"))
+		}
+	}
+	return names
+}
+
+// copyTemplateFS copies the files and symlinks in fsys into destDir
+func copyTemplateFS(fsys fs.FS, destDir string) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == "." {
+			return os.MkdirAll(destDir, 0755)
+		}
+		if skipTemplateEntry(p) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		target, err := safeTarget(destDir, p)
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			linkname, err := fs.ReadLink(fsys, p)
+			if err != nil {
+				return err
+			}
+			return createSymlink(destDir, p, linkname)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		src, err := fsys.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		return writeFile(target, src, info.Mode().Perm())
+	})
+}
+
+// skipTemplateEntry reports whether a path inside a template is local
+// build or runtime state, or local secrets, that should not be copied into
+// a new app
+func skipTemplateEntry(p string) bool {
+	for _, part := range strings.Split(p, "/") {
+		switch part {
+		case "node_modules", ".git", "tmp":
+			return true
+		}
+	}
+
+	base := path.Base(p)
+	return base == ".env" || base == secretsFile || strings.HasSuffix(base, ".db") || strings.HasSuffix(base, ".db-journal")
+}
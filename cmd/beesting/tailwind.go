@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// tailwindInput and tailwindOutput are the stylesheet paths inside an app
+	tailwindInput  = "input.css"
+	tailwindOutput = "static/output.css"
+
+	// maxTailwindBackoff caps the delay between restarts of a crashing watcher
+	maxTailwindBackoff = 30 * time.Second
+)
+
+// tailwindCommand returns the command that watches and rebuilds the app's
+// CSS, or nil if the app has no input.css or no Tailwind is available.
+//
+// The app's watch-css npm script wins, so apps pin their own Tailwind
+// version; otherwise the standalone tailwindcss binary is used.
+func tailwindCommand(appDir string) (*exec.Cmd, string) {
+	if _, err := os.Stat(filepath.Join(appDir, tailwindInput)); err != nil {
+		return nil, ""
+	}
+
+	if hasNpmScript(appDir, "watch-css") {
+		if _, err := exec.LookPath("npm"); err != nil {
+			return nil, "npm not found in PATH, CSS will not be rebuilt"
+		}
+		if _, err := os.Stat(filepath.Join(appDir, "node_modules")); err != nil {
+			return nil, "node_modules missing, run 'npm install' in " + appDir + " to rebuild CSS"
+		}
+		return exec.Command("npm", "run", "--silent", "watch-css"), ""
+	}
+
+	if _, err := exec.LookPath("tailwindcss"); err == nil {
+		return exec.Command("tailwindcss", "-i", tailwindInput, "-o", tailwindOutput, "--watch"), ""
+	}
+
+	return nil, "found input.css but no watch-css npm script or tailwindcss binary, CSS will not be rebuilt"
+}
+
+// buildCSS builds the app's CSS once, minified, into output, using the
+// Tailwind CLI the app installed with npm or the standalone tailwindcss
+// binary. The app's tracked stylesheet is left alone. Apps without an
+// input.css are skipped.
+func buildCSS(appDir, output string, out io.Writer) error {
+	if _, err := os.Stat(filepath.Join(appDir, tailwindInput)); err != nil {
+		return nil
+	}
+
+	// The build-css npm script has its output path built in, so run the
+	// app's own CLI directly
+	tailwind := filepath.Join(appDir, "node_modules", ".bin", "tailwindcss")
+	if _, err := os.Stat(tailwind); err != nil {
+		if tailwind, err = exec.LookPath("tailwindcss"); err != nil {
+			return fmt.Errorf("found %s but neither npm packages (run 'npm install') nor the tailwindcss binary", tailwindInput)
+		}
+	}
+
+	cssCmd := exec.Command(tailwind, "-i", tailwindInput, "-o", output, "--minify")
+	cssCmd.Dir = appDir
+	cssCmd.Stdout = out
+	cssCmd.Stderr = out
+	if err := cssCmd.Run(); err != nil {
+		return fmt.Errorf("failed to build CSS: %w", err)
+	}
+	return nil
+}
+
+// superviseTailwind runs the app's Tailwind watcher until ctx is done,
+// restarting it with a growing delay whenever it exits
+func (s *devServer) superviseTailwind(ctx context.Context) {
+	backoff := time.Second
+
+	for {
+		cssCmd, warning := tailwindCommand(s.appDir)
+		if cssCmd == nil {
+			if warning != "" {
+				s.logf("tailwind", "⚠️  %s", warning)
+			}
+			return
+		}
+
+		out := s.prefixWriter("tailwind")
+		cssCmd.Dir = s.appDir
+		cssCmd.Env = s.env
+		cssCmd.Stdout = out
+		cssCmd.Stderr = out
+
+		// Tailwind's --watch exits when stdin closes, so keep it open until
+		// we want the watcher to stop
+		stdin, err := cssCmd.StdinPipe()
+		if err != nil {
+			out.Close()
+			s.logf("tailwind", "❌ %v", err)
+			return
+		}
+
+		started := time.Now()
+		if err := cssCmd.Start(); err != nil {
+			out.Close()
+			s.logf("tailwind", "❌ Failed to start: %v", err)
+			return
+		}
+
+		exited := make(chan error, 1)
+		go func() { exited <- cssCmd.Wait() }()
+
+		select {
+		case <-ctx.Done():
+			stdin.Close()
+			select {
+			case <-exited:
+			case <-time.After(stopTimeout):
+				cssCmd.Process.Kill()
+				<-exited
+			}
+			out.Close()
+			return
+
+		case err := <-exited:
+			stdin.Close()
+			out.Close()
+
+			// A watcher that ran for a while gets a fresh backoff
+			if time.Since(started) > maxTailwindBackoff {
+				backoff = time.Second
+			}
+			s.logf("tailwind", "⚠️  Watcher exited (%v), restarting in %s", err, backoff)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, maxTailwindBackoff)
+		}
+	}
+}
+
+// hasNpmScript reports whether the app's package.json defines a script
+func hasNpmScript(appDir, script string) bool {
+	data, err := os.ReadFile(filepath.Join(appDir, "package.json"))
+	if err != nil {
+		return false
+	}
+
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return false
+	}
+	_, ok := pkg.Scripts[script]
+	return ok
+}
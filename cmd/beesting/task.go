@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nick-friedrich/beesting/pkg/beesting"
+	"github.com/spf13/cobra"
+)
+
+// taskCmd runs one-off tasks registered by an app
+var taskCmd = &cobra.Command{
+	Use:   "task <app> [name] [args...]",
+	Short: "Run a one-off task registered by an application",
+	Long: `Run a task the application registered with beesting.App.Task, such as
+cleaning up expired sessions or promoting a user. Without a name, list the
+app's tasks.
+
+The app is started with BEESTING_COMMAND=task, so main sets up config, the
+database and the mailer as usual, and beesting.App.Run then runs the task
+instead of serving. Tasks use the app's real database.`,
+	Args: cobra.MinimumNArgs(1),
+	// A failing task is not a usage error
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appDir, err := findAppDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 {
+			tasks, err := loadTasks(appDir)
+			if err != nil {
+				return err
+			}
+			if len(tasks) == 0 {
+				fmt.Printf("%s registers no tasks; add them with app.Task in main.go\n", appDir)
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TASK\tDESCRIPTION")
+			for _, task := range tasks {
+				fmt.Fprintf(w, "%s\t%s\n", task.Name, task.Description)
+			}
+			w.Flush()
+			return nil
+		}
+
+		name, taskArgs := args[1], args[2:]
+		if taskArgs == nil {
+			taskArgs = []string{}
+		}
+		encodedArgs, err := json.Marshal(taskArgs)
+		if err != nil {
+			return fmt.Errorf("failed to encode task arguments: %w", err)
+		}
+
+		runCmd := exec.Command("go", "run", "-tags", "dev", ".")
+		runCmd.Dir = appDir
+		runCmd.Env = append(appEnv(appDir),
+			beesting.CommandEnv+"=task",
+			beesting.TaskEnv+"="+name,
+			beesting.TaskArgsEnv+"="+string(encodedArgs),
+		)
+		runCmd.Stdin = os.Stdin
+		runCmd.Stdout = os.Stdout
+		runCmd.Stderr = os.Stderr
+
+		fmt.Fprintf(os.Stderr, "🐝 Running task %s\n", name)
+		start := time.Now()
+		if err := runCmd.Run(); err != nil {
+			return fmt.Errorf("task %s failed: %w", name, err)
+		}
+		fmt.Fprintf(os.Stderr, "✓ Task %s finished in %s\n", name, time.Since(start).Round(time.Millisecond))
+		return nil
+	},
+}
+
+func init() {
+	// Everything after the task name belongs to the task, including flags
+	taskCmd.Flags().SetInterspersed(false)
+}
+
+// loadTasks runs the app in task mode without a task name and decodes the
+// task list it prints
+func loadTasks(appDir string) ([]beesting.Task, error) {
+	var stdout, stderr bytes.Buffer
+
+	runCmd := exec.Command("go", "run", "-tags", "dev", ".")
+	runCmd.Dir = appDir
+	runCmd.Env = append(appEnv(appDir), beesting.CommandEnv+"=task", beesting.TaskEnv+"=")
+	runCmd.Stdout = &stdout
+	runCmd.Stderr = &stderr
+
+	if err := runCmd.Run(); err != nil {
+		os.Stderr.Write(stderr.Bytes())
+		return nil, fmt.Errorf("failed to run %s: %w", appDir, err)
+	}
+
+	// The app may print other output; the tasks are the JSON array line
+	scanner := bufio.NewScanner(&stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !bytes.HasPrefix(line, []byte("[")) {
+			continue
+		}
+
+		var tasks []beesting.Task
+		if err := json.Unmarshal(line, &tasks); err == nil {
+			return tasks, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s did not print its tasks; does main call beesting.App.Run?", appDir)
+}
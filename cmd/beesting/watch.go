@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// debounceDelay is how long the watcher waits for more changes before rebuilding
+	debounceDelay = 200 * time.Millisecond
+	// stopTimeout is how long the app gets to shut down before it is killed
+	stopTimeout = 5 * time.Second
+)
+
+// changeSet records which kinds of files changed since the last build
+type changeSet struct {
+	templ  bool
+	sql    bool
+	goSrc  bool
+	assets bool
+}
+
+func (c changeSet) empty() bool {
+	return !c.templ && !c.sql && !c.goSrc && !c.assets
+}
+
+// devServer rebuilds and restarts an app whenever its sources change
+type devServer struct {
+	name    string
+	appDir  string
+	binDir  string
+	env     []string
+	color   bool
+	running *exec.Cmd
+	exited  chan struct{}
+	mu      sync.Mutex
+
+	// onRestart and onAssets are called after the app restarted and after
+	// files in static/ changed, e.g. to reload browsers
+	onRestart func()
+	onAssets  func()
+}
+
+// newDevServer prepares a dev server that builds into a temporary directory
+func newDevServer(name, appDir string) (*devServer, error) {
+	binDir, err := os.MkdirTemp("", "beesting-dev-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create build directory: %w", err)
+	}
+
+	return &devServer{
+		name:   name,
+		appDir: appDir,
+		binDir: binDir,
+		env:    appEnv(appDir),
+		color:  isTerminal(os.Stdout),
+	}, nil
+}
+
+// Run builds and starts the app, then rebuilds on changes until ctx is done
+func (s *devServer) Run(ctx context.Context) error {
+	defer os.RemoveAll(s.binDir)
+	defer s.stop()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := s.watchTree(watcher, s.appDir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", s.appDir, err)
+	}
+
+	go s.superviseTailwind(ctx)
+
+	s.rebuild(changeSet{templ: true, sql: true, goSrc: true})
+
+	var pending changeSet
+	debounce := time.NewTimer(debounceDelay)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logf("watch", "Shutting down...")
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					s.watchTree(watcher, event.Name)
+					continue
+				}
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) &&
+				!event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+
+			if s.classify(event.Name, &pending) {
+				debounce.Reset(debounceDelay)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			s.logf("watch", "⚠️  %v", err)
+
+		case <-debounce.C:
+			changes := pending
+			pending = changeSet{}
+			s.rebuild(changes)
+		}
+	}
+}
+
+// watchTree adds dir and its subdirectories to the watcher
+func (s *devServer) watchTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != s.appDir && ignoreWatchDir(d.Name()) {
+			return fs.SkipDir
+		}
+		return watcher.Add(p)
+	})
+}
+
+// ignoreWatchDir reports whether a directory never contains app sources
+func ignoreWatchDir(name string) bool {
+	switch name {
+	case "node_modules", "tmp", "vendor":
+		return true
+	}
+	return strings.HasPrefix(name, ".")
+}
+
+// classify records a changed file in changes and reports whether it matters
+func (s *devServer) classify(p string, changes *changeSet) bool {
+	// Static files are served from disk, including the CSS Tailwind writes
+	if rel, err := filepath.Rel(s.appDir, p); err == nil && strings.HasPrefix(filepath.ToSlash(rel), "static/") {
+		changes.assets = true
+		return true
+	}
+
+	switch filepath.Ext(p) {
+	case ".templ":
+		changes.templ = true
+	case ".sql":
+		changes.sql = true
+	case ".go":
+		// Output of templ and sqlc is rebuilt from its sources
+		if isGeneratedGo(p) {
+			return false
+		}
+		changes.goSrc = true
+	default:
+		return false
+	}
+	return true
+}
+
+// rebuild runs the steps the changes need and restarts the app on success
+func (s *devServer) rebuild(changes changeSet) {
+	if changes.empty() {
+		return
+	}
+	start := time.Now()
+
+	if changes.templ || changes.sql {
+		for _, gen := range codeGenerators {
+			if gen.Name == "templ" && !changes.templ || gen.Name == "sqlc" && !changes.sql {
+				continue
+			}
+			if !gen.Applies(s.appDir) {
+				continue
+			}
+
+			out := s.prefixWriter(gen.Name)
+			err := gen.run(s.appDir, out)
+			out.Close()
+			if err != nil {
+				s.logf("build", "❌ %v", err)
+				return
+			}
+		}
+	}
+
+	// Static files, including the CSS Tailwind writes, are served from disk
+	// and need no restart
+	if !changes.templ && !changes.sql && !changes.goSrc {
+		if changes.assets && s.onAssets != nil {
+			// Reloading waits for the app, which may be down
+			go s.onAssets()
+		}
+		return
+	}
+
+	binPath := filepath.Join(s.binDir, s.name)
+	if runtime.GOOS == "windows" {
+		binPath += ".exe"
+	}
+	nextPath := binPath + ".next"
+
+	out := s.prefixWriter("build")
+	buildCmd := exec.Command("go", "build", "-tags", "dev", "-o", nextPath, ".")
+	buildCmd.Dir = s.appDir
+	buildCmd.Stdout = out
+	buildCmd.Stderr = out
+	err := buildCmd.Run()
+	out.Close()
+	if err != nil {
+		s.logf("build", "❌ Build failed, keeping the previous version running")
+		return
+	}
+
+	s.logf("build", "✓ Built in %s", time.Since(start).Round(time.Millisecond))
+	s.stop()
+
+	if err := os.Rename(nextPath, binPath); err != nil {
+		s.logf("build", "❌ %v", err)
+		return
+	}
+
+	if s.start(binPath) && s.onRestart != nil {
+		go s.onRestart()
+	}
+}
+
+// start runs the built binary in the app directory and reports whether it started
+func (s *devServer) start(binPath string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := s.prefixWriter(s.name)
+	appCmd := exec.Command(binPath)
+	appCmd.Dir = s.appDir
+	appCmd.Env = s.env
+	appCmd.Stdout = out
+	appCmd.Stderr = out
+
+	if err := appCmd.Start(); err != nil {
+		out.Close()
+		s.logf("build", "❌ Failed to start %s: %v", s.name, err)
+		return false
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		err := appCmd.Wait()
+		out.Close()
+		close(exited)
+
+		s.mu.Lock()
+		stillRunning := s.running == appCmd
+		s.mu.Unlock()
+		if stillRunning && err != nil {
+			s.logf("watch", "⚠️  %s exited: %v (waiting for changes)", s.name, err)
+		}
+	}()
+
+	s.running = appCmd
+	s.exited = exited
+	return true
+}
+
+// stop interrupts the running app and kills it if it doesn't exit in time
+func (s *devServer) stop() {
+	s.mu.Lock()
+	appCmd, exited := s.running, s.exited
+	s.running, s.exited = nil, nil
+	s.mu.Unlock()
+
+	if appCmd == nil {
+		return
+	}
+
+	select {
+	case <-exited:
+		return
+	default:
+	}
+
+	if runtime.GOOS == "windows" || appCmd.Process.Signal(os.Interrupt) != nil {
+		appCmd.Process.Kill()
+	}
+
+	select {
+	case <-exited:
+	case <-time.After(stopTimeout):
+		s.logf("watch", "⚠️  %s did not stop within %s, killing it", s.name, stopTimeout)
+		appCmd.Process.Kill()
+		<-exited
+	}
+}
+
+// logf prints a line with the given prefix
+func (s *devServer) logf(prefix, format string, args ...any) {
+	fmt.Fprintf(os.Stdout, "%s %s\n", s.label(prefix), fmt.Sprintf(format, args...))
+}
+
+// label formats a log prefix, colored on terminals
+func (s *devServer) label(prefix string) string {
+	label := fmt.Sprintf("[%s]", prefix)
+	if !s.color {
+		return label
+	}
+
+	colors := map[string]string{"watch": "36", "build": "33", "templ": "35", "sqlc": "34", "tailwind": "32", "reload": "36"}
+	color, ok := colors[prefix]
+	if !ok {
+		color = "1"
+	}
+	return "\033[" + color + "m" + label + "\033[0m"
+}
+
+// prefixWriter returns a writer that prints every line written to it with a prefix
+func (s *devServer) prefixWriter(prefix string) io.WriteCloser {
+	r, w := io.Pipe()
+	label := s.label(prefix)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			fmt.Fprintf(os.Stdout, "%s %s\n", label, scanner.Text())
+		}
+		io.Copy(io.Discard, r)
+	}()
+
+	return &lineWriter{w: w, done: done}
+}
+
+// lineWriter waits for its printer to flush when closed
+type lineWriter struct {
+	w    *io.PipeWriter
+	done chan struct{}
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	return l.w.Write(p)
+}
+
+func (l *lineWriter) Close() error {
+	err := l.w.Close()
+	<-l.done
+	return err
+}
+
+// isGeneratedGo reports whether a Go file carries the standard
+// "Code generated ... DO NOT EDIT." header
+func isGeneratedGo(p string) bool {
+	if strings.HasSuffix(p, "_templ.go") {
+		return true
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "package ") {
+			return false
+		}
+		if strings.HasPrefix(line, "// Code generated ") && strings.HasSuffix(line, " DO NOT EDIT.") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package beesting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// App is a beesting application: a router, its middleware chain and the
+// HTTP server that serves it
+type App struct {
+	router          chi.Router
+	mu              sync.Mutex // guards server and closed, which Shutdown uses from other goroutines
+	server          *http.Server
+	closed          bool
+	shutdownDone    chan struct{} // closed when the first Shutdown returns
+	shutdownOnce    sync.Once
+	shutdownTimeout time.Duration
+	tasks           []Task
+}
+
+// ErrAppClosed is returned by Run after Shutdown was called
+var ErrAppClosed = errors.New("beesting: app was shut down")
+
+// Option configures an App
+type Option func(*App)
+
+// WithShutdownTimeout sets how long Run waits for in-flight requests to
+// finish after receiving an interrupt signal
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(a *App) {
+		a.shutdownTimeout = timeout
+	}
+}
+
+// NewApp creates a new application with an empty router
+func NewApp(opts ...Option) *App {
+	app := &App{
+		router:          chi.NewRouter(),
+		shutdownDone:    make(chan struct{}),
+		shutdownTimeout: 10 * time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(app)
+	}
+
+	return app
+}
+
+// Router returns the underlying chi router
+func (a *App) Router() chi.Router {
+	return a.router
+}
+
+// ServeHTTP implements http.Handler so an App can be mounted or tested directly
+func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.router.ServeHTTP(w, r)
+}
+
+// Use appends middleware to the application's middleware chain.
+// Middleware must be added before any routes are registered.
+func (a *App) Use(middlewares ...Middleware) {
+	a.router.Use(middlewares...)
+}
+
+// Get registers a handler for GET requests
+func (a *App) Get(pattern string, handler http.HandlerFunc) {
+	a.router.Get(pattern, handler)
+}
+
+// Post registers a handler for POST requests
+func (a *App) Post(pattern string, handler http.HandlerFunc) {
+	a.router.Post(pattern, handler)
+}
+
+// Put registers a handler for PUT requests
+func (a *App) Put(pattern string, handler http.HandlerFunc) {
+	a.router.Put(pattern, handler)
+}
+
+// Patch registers a handler for PATCH requests
+func (a *App) Patch(pattern string, handler http.HandlerFunc) {
+	a.router.Patch(pattern, handler)
+}
+
+// Delete registers a handler for DELETE requests
+func (a *App) Delete(pattern string, handler http.HandlerFunc) {
+	a.router.Delete(pattern, handler)
+}
+
+// Handle registers a handler for all methods
+func (a *App) Handle(pattern string, handler http.Handler) {
+	a.router.Handle(pattern, handler)
+}
+
+// Route creates a sub-router mounted at pattern
+func (a *App) Route(pattern string, fn func(r chi.Router)) {
+	a.router.Route(pattern, fn)
+}
+
+// Group creates an inline group with its own middleware stack
+func (a *App) Group(fn func(r chi.Router)) {
+	a.router.Group(fn)
+}
+
+// Mount attaches another handler under pattern
+func (a *App) Mount(pattern string, handler http.Handler) {
+	a.router.Mount(pattern, handler)
+}
+
+// Static serves the files in dir under the given URL prefix, e.g.
+// app.Static("/static", "static/")
+func (a *App) Static(prefix, dir string) {
+	a.StaticFS(prefix, http.Dir(dir))
+}
+
+// StaticFS serves the files in fsys under the given URL prefix
+func (a *App) StaticFS(prefix string, fsys http.FileSystem) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		a.router.Handle("/*", http.FileServer(fsys))
+		return
+	}
+
+	prefix = "/" + prefix + "/"
+	a.router.Handle(prefix+"*", http.StripPrefix(prefix, http.FileServer(fsys)))
+}
+
+// NotFound sets the handler for unmatched routes
+func (a *App) NotFound(handler http.HandlerFunc) {
+	a.router.NotFound(handler)
+}
+
+// MethodNotAllowed sets the handler for routes that exist but not for the request method
+func (a *App) MethodNotAllowed(handler http.HandlerFunc) {
+	a.router.MethodNotAllowed(handler)
+}
+
+// Run starts the HTTP server on addr and blocks until it is stopped.
+// On SIGINT or SIGTERM the server is shut down gracefully, giving in-flight
+// requests up to the configured shutdown timeout to complete. When
+// Shutdown stops the server, Run returns once Shutdown has finished. An app
+// that was shut down can't be run again; Run returns ErrAppClosed.
+func (a *App) Run(addr string) error {
+	// `beesting routes` and `beesting task` run the app without serving
+	switch os.Getenv(CommandEnv) {
+	case "routes":
+		return a.writeRoutes(os.Stdout)
+	case "task":
+		return a.runTaskCommand(os.Stdout)
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           a.router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return ErrAppClosed
+	}
+	a.server = server
+	a.mu.Unlock()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("🐝 Server %s listening on %s", BuildInfo(), addr)
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			// Shutdown was called, let in-flight requests finish
+			<-a.shutdownDone
+			return nil
+		}
+		return fmt.Errorf("server error: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Println("🐝 Shutting down server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+	defer cancel()
+
+	return a.Shutdown(shutdownCtx)
+}
+
+// Shutdown gracefully stops a running server and keeps the app from
+// serving again. It is safe to call from another goroutine than Run,
+// before Run and more than once.
+func (a *App) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	a.closed = true
+	server := a.server
+	a.mu.Unlock()
+	defer a.shutdownOnce.Do(func() { close(a.shutdownDone) })
+
+	if server == nil {
+		return nil
+	}
+
+	if err := server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package beesting
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a local address nothing listens on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestShutdownBeforeRun(t *testing.T) {
+	app := NewApp()
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	addr := freeAddr(t)
+	done := make(chan error, 1)
+	go func() { done <- app.Run(addr) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrAppClosed) {
+			t.Errorf("Run() = %v, want ErrAppClosed", err)
+		}
+	case <-time.After(5 * time.Second):
+		app.server.Close()
+		t.Fatal("Run served after Shutdown")
+	}
+}
+
+func TestShutdownWhileRunning(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+
+	app := NewApp()
+	app.Get("/ping", func(w http.ResponseWriter, r *http.Request) {})
+	app.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+
+	addr := freeAddr(t)
+	runErr := make(chan error, 1)
+	go func() { runErr <- app.Run(addr) }()
+
+	// Wait for the server to come up
+	for i := 0; ; i++ {
+		resp, err := http.Get("http://" + addr + "/ping")
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		if i == 100 {
+			t.Fatalf("server didn't start: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownErr <- app.Shutdown(ctx)
+	}()
+
+	// Run waits for the in-flight request
+	select {
+	case err := <-runErr:
+		t.Fatalf("Run() = %v before the in-flight request finished", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(release)
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight request got %q, want done", got)
+	}
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("Shutdown() = %v", err)
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("Run() = %v, want nil", err)
+	}
+
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Errorf("second Shutdown() = %v", err)
+	}
+	if err := app.Run(addr); !errors.Is(err, ErrAppClosed) {
+		t.Errorf("Run() after Shutdown = %v, want ErrAppClosed", err)
+	}
+}
@@ -0,0 +1,33 @@
+package beesting
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Middleware wraps an http.Handler
+type Middleware = func(http.Handler) http.Handler
+
+// Logger logs the method, path, status and duration of every request
+func Logger() Middleware {
+	return middleware.Logger
+}
+
+// Recovery recovers from panics in handlers, logs the stack trace and
+// responds with 500 Internal Server Error
+func Recovery() Middleware {
+	return middleware.Recoverer
+}
+
+// RequestID attaches a unique request ID to the request context, reusing
+// the X-Request-Id header when the client sends one
+func RequestID() Middleware {
+	return middleware.RequestID
+}
+
+// RealIP sets the request's RemoteAddr from the X-Real-IP or
+// X-Forwarded-For headers. Only use it behind a trusted proxy.
+func RealIP() Middleware {
+	return middleware.RealIP
+}
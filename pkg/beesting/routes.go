@@ -0,0 +1,286 @@
+package beesting
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CommandEnv names the environment variable the beesting CLI uses to ask an
+// app to run a command instead of serving HTTP
+const CommandEnv = "BEESTING_COMMAND"
+
+// Route describes a registered route
+type Route struct {
+	Method      string   `json:"method"`
+	Pattern     string   `json:"pattern"`
+	Handler     string   `json:"handler"`
+	Middlewares []string `json:"middlewares"`
+}
+
+// Routes lists the app's routes, sorted by pattern and method
+func (a *App) Routes() ([]Route, error) {
+	var routes []Route
+
+	err := walk(a.router, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		names := make([]string, len(middlewares))
+		for i, mw := range middlewares {
+			names[i] = funcName(mw)
+		}
+
+		routes = append(routes, Route{
+			Method:      method,
+			Pattern:     route,
+			Handler:     handlerName(handler),
+			Middlewares: names,
+		})
+		return nil
+	}, "")
+	if err != nil {
+		return nil, err
+	}
+
+	routes = collapseAnyMethod(routes)
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes, nil
+}
+
+// walk is chi.Walk, except that routers mounted inside a Group keep the
+// group's middlewares, which chi.Walk leaves out
+func walk(r chi.Routes, fn chi.WalkFunc, parentRoute string, parentMw ...func(http.Handler) http.Handler) error {
+	for _, route := range r.Routes() {
+		mws := make([]func(http.Handler) http.Handler, len(parentMw))
+		copy(mws, parentMw)
+		mws = append(mws, r.Middlewares()...)
+
+		if route.SubRoutes != nil {
+			if chain, ok := route.Handlers["*"].(*chi.ChainHandler); ok {
+				mws = append(mws, chain.Middlewares...)
+			}
+			if err := walk(route.SubRoutes, fn, parentRoute+route.Pattern, mws...); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for method, handler := range route.Handlers {
+			// The catch-all entry duplicates the per-method ones
+			if method == "*" {
+				continue
+			}
+
+			fullRoute := strings.ReplaceAll(parentRoute+route.Pattern, "/*/", "/")
+			handlerMws := mws
+			if chain, ok := handler.(*chi.ChainHandler); ok {
+				handler = chain.Endpoint
+				handlerMws = append(handlerMws[:len(handlerMws):len(handlerMws)], chain.Middlewares...)
+			}
+			if err := fn(method, fullRoute, handler, handlerMws...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// allMethods are the methods chi registers for Handle and Mount
+var allMethods = []string{
+	http.MethodConnect, http.MethodDelete, http.MethodGet, http.MethodHead, http.MethodOptions,
+	http.MethodPatch, http.MethodPost, http.MethodPut, http.MethodTrace,
+}
+
+// collapseAnyMethod replaces the per-method entries of routes registered for
+// every method, such as static files, with a single "*" route
+func collapseAnyMethod(routes []Route) []Route {
+	byPattern := map[string][]Route{}
+	for _, route := range routes {
+		byPattern[route.Pattern] = append(byPattern[route.Pattern], route)
+	}
+
+	var collapsed []Route
+	done := map[string]bool{}
+	for _, route := range routes {
+		if done[route.Pattern] {
+			continue
+		}
+
+		group := byPattern[route.Pattern]
+		if len(group) == len(allMethods) && sameHandler(group) {
+			route.Method = "*"
+			collapsed = append(collapsed, route)
+			done[route.Pattern] = true
+			continue
+		}
+		collapsed = append(collapsed, route)
+	}
+	return collapsed
+}
+
+func sameHandler(routes []Route) bool {
+	for _, route := range routes[1:] {
+		if route.Handler != routes[0].Handler {
+			return false
+		}
+	}
+	return true
+}
+
+// writeRoutes writes the app's routes as JSON, for `beesting routes`
+func (a *App) writeRoutes(w io.Writer) error {
+	routes, err := a.Routes()
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(routes)
+}
+
+// RouteWarnings points out routes that don't behave the way they read.
+//
+// chi matches static segments before parameters, so GET /posts/new wins
+// over GET /posts/{slug} and the latter can never receive slug "new".
+// Parameters at the same position that are named differently across
+// routes are reported too, since they are easy to mix up in handlers.
+func RouteWarnings(routes []Route) []string {
+	var warnings []string
+
+	for _, param := range routes {
+		paramSegments := splitPattern(param.Pattern)
+		for i, segment := range paramSegments {
+			if !isParam(segment) {
+				continue
+			}
+
+			for _, static := range routes {
+				if static.Method != param.Method {
+					continue
+				}
+				staticSegments := splitPattern(static.Pattern)
+				if len(staticSegments) != len(paramSegments) || isParam(staticSegments[i]) || staticSegments[i] == "*" {
+					continue
+				}
+				if !sameShape(paramSegments, staticSegments, i) {
+					continue
+				}
+
+				warnings = append(warnings, fmt.Sprintf(
+					"%s %s never receives %s=%q: %s %s matches first",
+					param.Method, param.Pattern, paramName(segment), staticSegments[i], static.Method, static.Pattern,
+				))
+			}
+		}
+	}
+
+	// Different parameter names for the same position, e.g. /posts/{slug}
+	// and /posts/{id}/edit
+	seen := map[string]string{}
+	reported := map[string]bool{}
+	for _, route := range routes {
+		segments := splitPattern(route.Pattern)
+		for i, segment := range segments {
+			if !isParam(segment) {
+				continue
+			}
+
+			prefix := shapeKey(segments[:i])
+			name := paramName(segment)
+			if other, ok := seen[prefix]; ok && other != name {
+				key := prefix + "|" + other + "|" + name
+				if !reported[key] {
+					reported[key] = true
+					warnings = append(warnings, fmt.Sprintf(
+						"%s uses {%s} where other routes use {%s} for the same path segment",
+						route.Pattern, name, other,
+					))
+				}
+				continue
+			}
+			seen[prefix] = name
+		}
+	}
+
+	return warnings
+}
+
+// splitPattern splits a route pattern into its path segments
+func splitPattern(pattern string) []string {
+	return strings.Split(strings.Trim(pattern, "/"), "/")
+}
+
+// sameShape reports whether two patterns match the same paths apart from
+// segment skip, treating all parameters alike
+func sameShape(a, b []string, skip int) bool {
+	for i := range a {
+		if i == skip {
+			continue
+		}
+		if isParam(a[i]) && isParam(b[i]) {
+			continue
+		}
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// shapeKey identifies a list of segments with all parameters made anonymous
+func shapeKey(segments []string) string {
+	shape := make([]string, len(segments))
+	for i, segment := range segments {
+		if isParam(segment) {
+			shape[i] = "{}"
+		} else {
+			shape[i] = segment
+		}
+	}
+	return "/" + strings.Join(shape, "/")
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// paramName returns the name of a {name} or {name:regexp} segment
+func paramName(segment string) string {
+	name, _, _ := strings.Cut(strings.Trim(segment, "{}"), ":")
+	return name
+}
+
+var closureSuffix = regexp.MustCompile(`(\.func\d+|\.\d+)+$`)
+
+// handlerName returns a readable name for a handler, such as handler.ShowPosts
+func handlerName(h http.Handler) string {
+	if fn, ok := h.(http.HandlerFunc); ok {
+		return funcName(fn)
+	}
+	return reflect.TypeOf(h).String()
+}
+
+// funcName returns the package-qualified name of the function f, without
+// the import path and the suffixes Go gives closures
+func funcName(f any) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return closureSuffix.ReplaceAllString(name, "")
+}
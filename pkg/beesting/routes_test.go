@@ -0,0 +1,122 @@
+package beesting
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRouteWarnings(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []Route
+		want   []string
+	}{
+		{
+			name: "consistent routes",
+			routes: []Route{
+				{Method: "GET", Pattern: "/posts/"},
+				{Method: "GET", Pattern: "/posts/{id}"},
+				{Method: "GET", Pattern: "/posts/{id}/edit"},
+				{Method: "POST", Pattern: "/posts/{id}/edit"},
+			},
+		},
+		{
+			name: "static segment shadows a parameter",
+			routes: []Route{
+				{Method: "GET", Pattern: "/posts/{slug}"},
+				{Method: "GET", Pattern: "/posts/new"},
+			},
+			want: []string{`GET /posts/{slug} never receives slug="new": GET /posts/new matches first`},
+		},
+		{
+			name: "other methods don't shadow",
+			routes: []Route{
+				{Method: "GET", Pattern: "/posts/{slug}"},
+				{Method: "POST", Pattern: "/posts/new"},
+			},
+		},
+		{
+			name: "other lengths don't shadow",
+			routes: []Route{
+				{Method: "GET", Pattern: "/posts/{slug}"},
+				{Method: "GET", Pattern: "/posts/new/draft"},
+			},
+		},
+		{
+			name: "wildcards don't shadow",
+			routes: []Route{
+				{Method: "GET", Pattern: "/static/{file}"},
+				{Method: "GET", Pattern: "/static/*"},
+			},
+		},
+		{
+			name: "different parameter names are reported once",
+			routes: []Route{
+				{Method: "GET", Pattern: "/posts/{slug}"},
+				{Method: "GET", Pattern: "/posts/{id}/edit"},
+				{Method: "POST", Pattern: "/posts/{id}/edit"},
+			},
+			want: []string{"/posts/{id}/edit uses {id} where other routes use {slug} for the same path segment"},
+		},
+		{
+			name: "regexp parameters are compared by name",
+			routes: []Route{
+				{Method: "GET", Pattern: "/posts/{id:[0-9]+}"},
+				{Method: "GET", Pattern: "/posts/{id}/edit"},
+			},
+		},
+		{
+			name: "nested parameters",
+			routes: []Route{
+				{Method: "GET", Pattern: "/users/{id}/posts/{slug}"},
+				{Method: "GET", Pattern: "/users/{uid}/posts/new"},
+			},
+			want: []string{
+				`GET /users/{id}/posts/{slug} never receives slug="new": GET /users/{uid}/posts/new matches first`,
+				"/users/{uid}/posts/new uses {uid} where other routes use {id} for the same path segment",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RouteWarnings(tt.routes)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("RouteWarnings() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoutesListsGroupMiddlewares(t *testing.T) {
+	auth := func(next http.Handler) http.Handler { return next }
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	app := NewApp()
+	app.Get("/health", ok)
+	app.Group(func(r chi.Router) {
+		r.Use(auth)
+		r.Get("/", ok)
+		r.Route("/posts", func(r chi.Router) {
+			r.Get("/", ok)
+		})
+	})
+
+	routes, err := app.Routes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"/health": 0, "/": 1, "/posts/": 1}
+	if len(routes) != len(want) {
+		t.Fatalf("Routes() = %v, want %d routes", routes, len(want))
+	}
+	for _, route := range routes {
+		if n, ok := want[route.Pattern]; !ok || len(route.Middlewares) != n {
+			t.Errorf("%s %s has middlewares %v, want %d", route.Method, route.Pattern, route.Middlewares, n)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package beesting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// TaskEnv and TaskArgsEnv name the task `beesting task` asks an app to run
+// and its arguments, encoded as a JSON array
+const (
+	TaskEnv     = "BEESTING_TASK"
+	TaskArgsEnv = "BEESTING_TASK_ARGS"
+)
+
+// TaskFunc runs a task with the arguments given on the command line. The
+// context is cancelled on SIGINT or SIGTERM.
+type TaskFunc func(ctx context.Context, args []string) error
+
+// Task is a named one-off job, such as cleaning up expired sessions
+type Task struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Run         TaskFunc `json:"-"`
+}
+
+// Task registers a task that `beesting task <app> <name>` runs after main
+// has set up everything it does before Run. Names are usually namespaced,
+// like "users:promote".
+func (a *App) Task(name, description string, fn TaskFunc) {
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		panic(fmt.Sprintf("beesting: invalid task name %q", name))
+	}
+	if _, ok := a.lookupTask(name); ok {
+		panic(fmt.Sprintf("beesting: task %q registered twice", name))
+	}
+
+	a.tasks = append(a.tasks, Task{Name: name, Description: description, Run: fn})
+}
+
+// Tasks lists the registered tasks in registration order
+func (a *App) Tasks() []Task {
+	return append([]Task(nil), a.tasks...)
+}
+
+// RunTask runs the task name with args
+func (a *App) RunTask(ctx context.Context, name string, args []string) error {
+	task, ok := a.lookupTask(name)
+	if !ok {
+		names := make([]string, len(a.tasks))
+		for i, task := range a.tasks {
+			names[i] = task.Name
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("unknown task %q: the app registers no tasks", name)
+		}
+		return fmt.Errorf("unknown task %q, available: %s", name, strings.Join(names, ", "))
+	}
+
+	if err := task.Run(ctx, args); err != nil {
+		return fmt.Errorf("task %s failed: %w", name, err)
+	}
+	return nil
+}
+
+func (a *App) lookupTask(name string) (Task, bool) {
+	for _, task := range a.tasks {
+		if task.Name == name {
+			return task, true
+		}
+	}
+	return Task{}, false
+}
+
+// runTaskCommand handles BEESTING_COMMAND=task: it runs the task named by
+// BEESTING_TASK, or lists the tasks as JSON when none is named
+func (a *App) runTaskCommand(w io.Writer) error {
+	name := os.Getenv(TaskEnv)
+	if name == "" {
+		tasks := a.Tasks()
+		if tasks == nil {
+			tasks = []Task{}
+		}
+		return json.NewEncoder(w).Encode(tasks)
+	}
+
+	var args []string
+	if raw := os.Getenv(TaskArgsEnv); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &args); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", TaskArgsEnv, err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return a.RunTask(ctx, name, args)
+}
@@ -0,0 +1,16 @@
+package beesting
+
+// Version and Commit identify the running build. `beesting build` sets them
+// with -ldflags "-X github.com/nick-friedrich/beesting/pkg/beesting.Version=..."
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+// BuildInfo returns the version and, when known, the commit of the running build
+func BuildInfo() string {
+	if Commit == "" {
+		return Version
+	}
+	return Version + " (" + Commit + ")"
+}